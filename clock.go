@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clockOffset = prometheus.NewDesc("keeneteus_clock_offset_seconds",
+		"Router clock minus exporter clock at the time of the poll",
+		nil, nil)
+	clockTimezone = prometheus.NewDesc("keeneteus_clock_timezone_info",
+		"Router timezone, value is always 1",
+		[]string{"locality", "rule", "dst"}, nil)
+)
+
+// clockCollector exports router clock drift from show clock date
+type clockCollector struct {
+	snap *snapshot
+}
+
+func (c *clockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clockOffset
+	ch <- clockTimezone
+}
+
+func (c *clockCollector) Collect(ch chan<- prometheus.Metric) {
+	var m, updated = c.snap.getMetricsAt()
+	if m == nil {
+		return
+	}
+	var d = &m.Show.Clock.Date
+	if d.Year == 0 {
+		return
+	}
+
+	// Роутер отдает локальное время
+	var loc = routerLocation(m)
+	if loc == nil {
+		loc = time.UTC
+	} else {
+		ch <- prometheus.MustNewConstMetric(clockTimezone, prometheus.GaugeValue, 1, d.Tz[0].Locality, d.Tz[0].Rule, d.Dst)
+	}
+
+	var router = time.Date(d.Year, time.Month(d.Month), d.Day, d.Hour, d.Min, d.Sec, d.Msec*int(time.Millisecond), loc)
+	ch <- prometheus.MustNewConstMetric(clockOffset, prometheus.GaugeValue, router.Sub(updated).Seconds())
+}
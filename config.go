@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+// config файл конфигурации экспортера, путь задается в KeeneticConfig
+type config struct {
+	// Interfaces интерфейсы для show interface stat
+	Interfaces []keenetic_api.Eth `json:"interfaces"`
+	// Devices устройства для графика трафика ip hotspot chart
+	Devices []keenetic_api.Eth `json:"devices"`
+	// Peers алиасы пиров WireGuard, Code - публичный ключ
+	Peers []keenetic_api.Eth `json:"peers"`
+
+	Hosts hostFilter `json:"hosts"`
+
+	// PasswordFile файл с паролем роутера
+	PasswordFile string `json:"password_file"`
+	// PasswordCommand команда, печатающая пароль роутера в stdout
+	PasswordCommand string `json:"password_command"`
+}
+
+// hostFilter limits the hosts exported with per-host labels
+type hostFilter struct {
+	// MaxHosts максимум хостов с собственными метриками, 0 - без ограничения
+	MaxHosts int `json:"max_hosts"`
+	// Allow и Deny содержат MAC, OUI (первые три октета) или имя интерфейса
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+	// CollapseUnknown незарегистрированные хосты попадают в общий хост other
+	CollapseUnknown bool `json:"collapse_unknown"`
+	// TTL хосты, не появлявшиеся дольше TTL, не экспортируются
+	TTL duration `json:"ttl"`
+}
+
+// duration time.Duration written as "10m" in the config
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	var v, err = time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Interfaces: []keenetic_api.Eth{
+			{Name: "DOM.RU", Code: "GigabitEthernet0/Vlan4"},
+			{Name: "Mishek.NET", Code: "GigabitEthernet1"},
+			{Name: "WGHetzner", Code: "Wireguard0"},
+			{Name: "OfficeVPN", Code: "OpenVPN0"}},
+		Devices: []keenetic_api.Eth{
+			{Name: "StanislavPC", Code: "18:c0:4d:64:4c:1e"},
+			{Name: "MacBook 2015", Code: "a8:66:7f:2e:4a:d2"},
+			{Name: "OnePlus6T", Code: "c0:ee:fb:4c:60:fd"},
+			{Name: "Multicast", Code: "multicast"},
+			{Name: "Others", Code: "others"}},
+	}
+}
+
+// loadConfig reads the config file, without a path the defaults are used
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path == "" {
+		return defaultConfig(), nil
+	}
+
+	var err error
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	if err = json.NewDecoder(f).Decode(&cfg); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+var (
+	errNoInterfaces = errors.New("config: interfaces must not be empty")
+	errNoDevices    = errors.New("config: devices must not be empty")
+	errBadHosts     = errors.New("config: hosts.max_hosts and hosts.ttl must not be negative")
+)
+
+// validate проверяет конфиг до применения, пустые списки ломают запрос InterfaceStat
+func (c *config) validate() error {
+	if len(c.Interfaces) == 0 {
+		return errNoInterfaces
+	}
+	if len(c.Devices) == 0 {
+		return errNoDevices
+	}
+	for _, list := range [][]keenetic_api.Eth{c.Interfaces, c.Devices, c.Peers} {
+		for _, v := range list {
+			if v.Name == "" || v.Code == "" {
+				return fmt.Errorf("config: name and code are required, got %+v", v)
+			}
+		}
+	}
+	// Имена становятся значениями меток, повтор дает одинаковые серии
+	for _, list := range []struct {
+		field string
+		items []keenetic_api.Eth
+	}{{"interfaces", c.Interfaces}, {"devices", c.Devices}, {"peers", c.Peers}} {
+		var seen = make(map[string]bool, len(list.items))
+		for _, v := range list.items {
+			if seen[v.Name] {
+				return fmt.Errorf("config: duplicate name %q in %s", v.Name, list.field)
+			}
+			seen[v.Name] = true
+		}
+	}
+	// Пир без алиаса называется своим ключом, алиас не должен совпадать с ключом другого пира
+	for k, v := range c.Peers {
+		for j, o := range c.Peers {
+			if k != j && v.Name == o.Code {
+				return fmt.Errorf("config: peer name %q is the public key of another peer", v.Name)
+			}
+		}
+	}
+	if c.Hosts.MaxHosts < 0 || c.Hosts.TTL < 0 {
+		return errBadHosts
+	}
+	return nil
+}
+
+// configHolder текущий конфиг, подменяется целиком при перезагрузке
+type configHolder struct {
+	p atomic.Pointer[config]
+}
+
+func (h *configHolder) get() *config {
+	return h.p.Load()
+}
+
+func (h *configHolder) set(c *config) {
+	h.p.Store(c)
+}
+
+type hostClass int
+
+const (
+	hostExport hostClass = iota
+	hostOther
+	hostDrop
+)
+
+// classify decides whether the host is exported by itself, collapsed into other or dropped
+func (f *hostFilter) classify(h *keenetic_api.Host) hostClass {
+	if f.matches(f.Deny, h) {
+		return hostDrop
+	}
+	if f.TTL > 0 && !h.Active && time.Duration(h.LastSeen)*time.Second > time.Duration(f.TTL) {
+		return hostDrop
+	}
+	if f.matches(f.Allow, h) {
+		return hostExport
+	}
+	if len(f.Allow) > 0 || (f.CollapseUnknown && !h.Registered) {
+		return f.rest()
+	}
+	return hostExport
+}
+
+// rest class of the hosts over the limits
+func (f *hostFilter) rest() hostClass {
+	if f.CollapseUnknown {
+		return hostOther
+	}
+	return hostDrop
+}
+
+func (f *hostFilter) matches(list []string, h *keenetic_api.Host) bool {
+	var mac = strings.ToLower(h.Mac)
+	for _, v := range list {
+		v = strings.ToLower(v)
+		if v == mac || (len(v) == 8 && strings.HasPrefix(mac, v)) ||
+			v == strings.ToLower(h.Interface.Name) || v == strings.ToLower(h.Interface.Id) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter splits hosts into exported and collapsed ones, honouring MaxHosts.
+// MaxHosts - жесткий предел: allow принимает OUI и интерфейсы, поэтому от предела не освобождает.
+// Зарегистрированные хосты получают приоритет, порядок стабилен между опросами.
+func (f *hostFilter) filter(hosts []keenetic_api.Host) (export, other []*keenetic_api.Host) {
+	for k := range hosts {
+		switch f.classify(&hosts[k]) {
+		case hostExport:
+			export = append(export, &hosts[k])
+		case hostOther:
+			other = append(other, &hosts[k])
+		}
+	}
+
+	if f.MaxHosts > 0 && len(export) > f.MaxHosts {
+		sort.SliceStable(export, func(i, j int) bool {
+			if export[i].Registered != export[j].Registered {
+				return export[i].Registered
+			}
+			return export[i].Mac < export[j].Mac
+		})
+		if f.rest() == hostOther {
+			other = append(other, export[f.MaxHosts:]...)
+		}
+		export = export[:f.MaxHosts]
+	}
+
+	return export, other
+}
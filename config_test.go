@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+func testHost(mac string, registered, active bool, lastSeen int, iface string) keenetic_api.Host {
+	var h = keenetic_api.Host{Mac: mac, Registered: registered, Active: active, LastSeen: lastSeen}
+	h.Interface.Name = iface
+	return h
+}
+
+func macs(hosts []*keenetic_api.Host) []string {
+	var r []string
+	for _, h := range hosts {
+		r = append(r, h.Mac)
+	}
+	return r
+}
+
+func TestHostFilter(t *testing.T) {
+	var hosts = []keenetic_api.Host{
+		testHost("aa:00:00:00:00:03", true, true, 0, "Home"),
+		testHost("aa:00:00:00:00:01", false, true, 0, "Home"),
+		testHost("bb:00:00:00:00:02", true, false, 3600, "Home"),
+		testHost("cc:00:00:00:00:04", true, true, 0, "GuestWiFi"),
+		testHost("dd:00:00:00:00:05", false, true, 0, "Home"),
+	}
+
+	var tests = []struct {
+		name   string
+		f      hostFilter
+		export []string
+		other  []string
+	}{
+		{"no limits", hostFilter{},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01", "bb:00:00:00:00:02", "cc:00:00:00:00:04", "dd:00:00:00:00:05"}, nil},
+		{"deny by interface", hostFilter{Deny: []string{"guestwifi"}},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01", "bb:00:00:00:00:02", "dd:00:00:00:00:05"}, nil},
+		{"ttl", hostFilter{TTL: duration(time.Minute)},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01", "cc:00:00:00:00:04", "dd:00:00:00:00:05"}, nil},
+		{"allow by oui", hostFilter{Allow: []string{"AA:00:00"}},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01"}, nil},
+		{"allow with collapse", hostFilter{Allow: []string{"aa:00:00"}, CollapseUnknown: true},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01"},
+			[]string{"bb:00:00:00:00:02", "cc:00:00:00:00:04", "dd:00:00:00:00:05"}},
+		{"collapse unknown", hostFilter{CollapseUnknown: true},
+			[]string{"aa:00:00:00:00:03", "bb:00:00:00:00:02", "cc:00:00:00:00:04"},
+			[]string{"aa:00:00:00:00:01", "dd:00:00:00:00:05"}},
+		{"max hosts prefers registered", hostFilter{MaxHosts: 2},
+			[]string{"aa:00:00:00:00:03", "bb:00:00:00:00:02"}, nil},
+		{"max hosts collapses the rest", hostFilter{MaxHosts: 3, CollapseUnknown: true},
+			[]string{"aa:00:00:00:00:03", "bb:00:00:00:00:02", "cc:00:00:00:00:04"},
+			[]string{"aa:00:00:00:00:01", "dd:00:00:00:00:05"}},
+		{"max hosts caps allowed", hostFilter{MaxHosts: 2, Allow: []string{"dd:00:00:00:00:05", "aa:00:00:00:00:01", "aa:00:00:00:00:03"}},
+			[]string{"aa:00:00:00:00:03", "aa:00:00:00:00:01"}, nil},
+		{"max hosts caps allowed interface", hostFilter{MaxHosts: 1, Allow: []string{"home"}, CollapseUnknown: true},
+			[]string{"aa:00:00:00:00:03"},
+			[]string{"cc:00:00:00:00:04", "bb:00:00:00:00:02", "aa:00:00:00:00:01", "dd:00:00:00:00:05"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var export, other = tt.f.filter(hosts)
+			if got := macs(export); !reflect.DeepEqual(got, tt.export) {
+				t.Errorf("export = %v, want %v", got, tt.export)
+			}
+			if got := macs(other); !reflect.DeepEqual(got, tt.other) {
+				t.Errorf("other = %v, want %v", got, tt.other)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	var eth = func(name, code string) keenetic_api.Eth { return keenetic_api.Eth{Name: name, Code: code} }
+	var tests = []struct {
+		name string
+		cfg  config
+		ok   bool
+	}{
+		{"defaults", defaultConfig(), true},
+		{"no interfaces", config{Devices: []keenetic_api.Eth{eth("pc", "others")}}, false},
+		{"no devices", config{Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")}}, false},
+		{"empty code", config{Interfaces: []keenetic_api.Eth{eth("wan", "")}, Devices: []keenetic_api.Eth{eth("pc", "others")}}, false},
+		{"duplicate interface", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1"), eth("wan", "GigabitEthernet0/Vlan4")},
+			Devices:    []keenetic_api.Eth{eth("pc", "others")}}, false},
+		{"duplicate device", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")},
+			Devices:    []keenetic_api.Eth{eth("pc", "18:c0:4d:64:4c:1e"), eth("pc", "others")}}, false},
+		{"same name in different lists", config{
+			Interfaces: []keenetic_api.Eth{eth("home", "Bridge0")},
+			Devices:    []keenetic_api.Eth{eth("home", "others")}}, true},
+		{"duplicate peer", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")},
+			Devices:    []keenetic_api.Eth{eth("pc", "others")},
+			Peers:      []keenetic_api.Eth{eth("vps", "key1="), eth("vps", "key2=")}}, false},
+		{"peer name is another key", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")},
+			Devices:    []keenetic_api.Eth{eth("pc", "others")},
+			Peers:      []keenetic_api.Eth{eth("key2=", "key1="), eth("vps", "key2=")}}, false},
+		{"peer named by own key", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")},
+			Devices:    []keenetic_api.Eth{eth("pc", "others")},
+			Peers:      []keenetic_api.Eth{eth("key1=", "key1="), eth("vps", "key2=")}}, true},
+		{"negative max hosts", config{
+			Interfaces: []keenetic_api.Eth{eth("wan", "GigabitEthernet1")},
+			Devices:    []keenetic_api.Eth{eth("pc", "others")},
+			Hosts:      hostFilter{MaxHosts: -1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// getenv returns the variable or, if name_FILE is set, the trimmed contents of that file (Docker/Kubernetes secrets)
+func getenv(name string) (string, error) {
+	var path = os.Getenv(name + "_FILE")
+	if path == "" {
+		return os.Getenv(name), nil
+	}
+
+	var b, err = os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// routerPassword source of the router password, checked in order: command helper
+// (KeeneticPasswordCommand or password_command), file (KeeneticPassword_FILE or password_file), KeeneticPassword.
+// Пароль и его источник определяются заново при каждой авторизации, поэтому перезагрузка конфига применяется сразу.
+func routerPassword(cfg *configHolder) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		var c = cfg.get()
+		var command = os.Getenv("KeeneticPasswordCommand")
+		if command == "" {
+			command = c.PasswordCommand
+		}
+		var file = os.Getenv("KeeneticPassword_FILE")
+		if file == "" {
+			file = c.PasswordFile
+		}
+
+		switch {
+		case command != "":
+			return runPasswordCommand(command)
+		case file != "":
+			var b, err = os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			return bytes.TrimRight(b, "\r\n"), nil
+		}
+
+		return []byte(os.Getenv("KeeneticPassword")), nil
+	}
+}
+
+// passwordCommandTimeout зависший helper (недоступный vault agent, интерактивный запрос) не должен блокировать Auth
+const passwordCommandTimeout = 30 * time.Second
+
+var errEmptyPassword = errors.New("password command printed nothing")
+
+// runPasswordCommand runs the credential helper and returns its stdout without the trailing newline
+func runPasswordCommand(command string) ([]byte, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+
+	var cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	// Потомки sh могут держать stdout открытым после kill
+	cmd.WaitDelay = time.Second
+
+	var out, err = cmd.Output()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("password command timed out after %v", passwordCommandTimeout)
+	case err != nil:
+		return nil, fmt.Errorf("password command failed: %w", err)
+	}
+
+	out = bytes.TrimRight(out, "\r\n")
+	if len(out) == 0 {
+		return nil, errEmptyPassword
+	}
+	return out, nil
+}
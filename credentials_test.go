@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunPasswordCommand(t *testing.T) {
+	var tests = []struct {
+		name    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{"prints password", "printf 'secret\\n'", "secret", false},
+		{"non-zero exit", "echo secret; exit 3", "", true},
+		{"prints nothing", "true", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, err = runPasswordCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("password = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := runPasswordCommand("true"); !errors.Is(err, errEmptyPassword) {
+		t.Errorf("empty output: error = %v, want %v", err, errEmptyPassword)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+// version задается при сборке: -ldflags "-X main.version=..."
+var version = "dev"
+
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Keeneteus exporter</title></head>
+<body>
+<h1>Keeneteus exporter</h1>
+<p>Version: {{.Version}}</p>
+{{with .Router}}<p>Router: {{.Show.Version.Model}} {{.Show.System.Hostname}}, KeeneticOS {{.Show.Version.Release}}</p>{{end}}
+<p>Last poll: {{if .Updated.IsZero}}never{{else}}{{.Updated.Format "2006-01-02 15:04:05 MST"}}{{end}}</p>
+<ul>
+<li><a href="/metrics">Metrics</a></li>
+<li><a href="/-/healthy">Health</a></li>
+<li><a href="/-/ready">Readiness</a></li>
+</ul>
+</body>
+</html>
+`))
+
+// health отвечает на пробы Kubernetes и отдает стартовую страницу
+type health struct {
+	snap       *snapshot
+	authorized func() bool
+	// maxAge опрос роутера считается свежим в течение этого времени
+	maxAge time.Duration
+}
+
+// healthy process is alive
+func (h *health) healthy(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+// ready authorized on the router and the last poll is recent enough
+func (h *health) ready(w http.ResponseWriter, _ *http.Request) {
+	if !h.authorized() {
+		http.Error(w, "not authorized on the router", http.StatusServiceUnavailable)
+		return
+	}
+
+	var _, updated = h.snap.getMetricsAt()
+	if updated.IsZero() || time.Since(updated) > h.maxAge {
+		http.Error(w, "no recent poll of the router", http.StatusServiceUnavailable)
+		return
+	}
+
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+func (h *health) landing(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var m, updated = h.snap.getMetricsAt()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = landingTemplate.Execute(w, struct {
+		Version string
+		Router  *keenetic_api.Metrics
+		Updated time.Time
+	}{version, m, updated})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	hostInfo = prometheus.NewDesc("keeneteus_host_info",
+		"Host known to the router, value is always 1",
+		[]string{"mac", "ip", "hostname", "name", "interface", "registered", "access"}, nil)
+	hostActive = prometheus.NewDesc("keeneteus_host_active",
+		"Whether the host is active",
+		[]string{"mac"}, nil)
+	hostLastSeen = prometheus.NewDesc("keeneteus_host_last_seen_timestamp_seconds",
+		"Time the host was last seen by the router",
+		[]string{"mac"}, nil)
+	hostDhcpExpiry = prometheus.NewDesc("keeneteus_host_dhcp_lease_expiry_seconds",
+		"Seconds until the DHCP lease of the host expires",
+		[]string{"mac"}, nil)
+	hostRssi = prometheus.NewDesc("keeneteus_host_rssi",
+		"Signal strength of the wireless host",
+		[]string{"mac"}, nil)
+	hostsCollapsed = prometheus.NewDesc("keeneteus_hosts_collapsed",
+		"Number of hosts exported only as part of mac=\"other\"",
+		nil, nil)
+	hostsCollapsedRx = prometheus.NewDesc("keeneteus_hosts_collapsed_receive_bytes",
+		"Sum of bytes received by the collapsed hosts, drops when hosts leave the group",
+		nil, nil)
+	hostsCollapsedTx = prometheus.NewDesc("keeneteus_hosts_collapsed_transmit_bytes",
+		"Sum of bytes sent by the collapsed hosts, drops when hosts leave the group",
+		nil, nil)
+	hostRx = prometheus.NewDesc("keeneteus_host_receive_bytes_total",
+		"Bytes received by the host",
+		[]string{"mac"}, nil)
+	hostTx = prometheus.NewDesc("keeneteus_host_transmit_bytes_total",
+		"Bytes sent by the host",
+		[]string{"mac"}, nil)
+	hostRxLimit = prometheus.NewDesc("keeneteus_host_receive_limit_bits_per_second",
+		"Traffic shaping limit for data received by the host",
+		[]string{"mac"}, nil)
+	hostTxLimit = prometheus.NewDesc("keeneteus_host_transmit_limit_bits_per_second",
+		"Traffic shaping limit for data sent by the host",
+		[]string{"mac"}, nil)
+	hostShapeInfo = prometheus.NewDesc("keeneteus_host_traffic_shape_info",
+		"Traffic shaping mode and schedule of the host, value is always 1",
+		[]string{"mac", "mode", "schedule"}, nil)
+	hostAccessDenied = prometheus.NewDesc("keeneteus_host_access_denied",
+		"Whether internet access of the host is denied",
+		[]string{"mac"}, nil)
+	hostSchedule = prometheus.NewDesc("keeneteus_host_schedule_info",
+		"Access schedule assigned to the host, value is always 1",
+		[]string{"mac", "schedule"}, nil)
+)
+
+// kbit лимиты traffic-shape роутер отдает в кбит/с
+const kbit = 1000
+
+// otherHost mac свернутых хостов
+const otherHost = "other"
+
+// hostsCollector exports show ip hotspot keyed by MAC
+type hostsCollector struct {
+	snap *snapshot
+	cfg  *configHolder
+}
+
+func (c *hostsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hostInfo
+	ch <- hostActive
+	ch <- hostLastSeen
+	ch <- hostDhcpExpiry
+	ch <- hostRssi
+	ch <- hostsCollapsed
+	ch <- hostsCollapsedRx
+	ch <- hostsCollapsedTx
+	ch <- hostRx
+	ch <- hostTx
+	ch <- hostRxLimit
+	ch <- hostTxLimit
+	ch <- hostShapeInfo
+	ch <- hostAccessDenied
+	ch <- hostSchedule
+}
+
+func (c *hostsCollector) Collect(ch chan<- prometheus.Metric) {
+	var m, updated = c.snap.getMetricsAt()
+	if m == nil {
+		return
+	}
+
+	var export, other = c.cfg.get().Hosts.filter(m.Show.Ip.Hotspot.Host)
+	for _, v := range export {
+		ch <- prometheus.MustNewConstMetric(hostInfo, prometheus.GaugeValue, 1,
+			v.Mac, v.Ip, v.Hostname, v.Name, v.Interface.Name, strconv.FormatBool(v.Registered), v.Access)
+		ch <- prometheus.MustNewConstMetric(hostActive, prometheus.GaugeValue, boolToFloat(v.Active), v.Mac)
+		// last-seen - секунды с момента, когда роутер последний раз видел хост
+		ch <- prometheus.MustNewConstMetric(hostLastSeen, prometheus.GaugeValue,
+			float64(updated.Add(-time.Duration(v.LastSeen)*time.Second).Unix()), v.Mac)
+		if v.Dhcp.Expires > 0 {
+			ch <- prometheus.MustNewConstMetric(hostDhcpExpiry, prometheus.GaugeValue, float64(v.Dhcp.Expires), v.Mac)
+		}
+		if v.Rssi != 0 {
+			ch <- prometheus.MustNewConstMetric(hostRssi, prometheus.GaugeValue, float64(v.Rssi), v.Mac)
+		}
+
+		ch <- prometheus.MustNewConstMetric(hostRx, prometheus.CounterValue, float64(v.Rxbytes), v.Mac)
+		ch <- prometheus.MustNewConstMetric(hostTx, prometheus.CounterValue, float64(v.Txbytes), v.Mac)
+		ch <- prometheus.MustNewConstMetric(hostAccessDenied, prometheus.GaugeValue, boolToFloat(v.Access == "deny"), v.Mac)
+		if v.Schedule != "" {
+			ch <- prometheus.MustNewConstMetric(hostSchedule, prometheus.GaugeValue, 1, v.Mac, v.Schedule)
+		}
+
+		// Нулевой лимит - ограничение не задано
+		var shape = &v.TrafficShape
+		if shape.Rx > 0 {
+			ch <- prometheus.MustNewConstMetric(hostRxLimit, prometheus.GaugeValue, float64(shape.Rx)*kbit, v.Mac)
+		}
+		if shape.Tx > 0 {
+			ch <- prometheus.MustNewConstMetric(hostTxLimit, prometheus.GaugeValue, float64(shape.Tx)*kbit, v.Mac)
+		}
+		if shape.Rx > 0 || shape.Tx > 0 || shape.Schedule != "" {
+			ch <- prometheus.MustNewConstMetric(hostShapeInfo, prometheus.GaugeValue, 1, v.Mac, shape.Mode, shape.Schedule)
+		}
+	}
+
+	// Сумма свернутых хостов уменьшается при смене состава, поэтому отдается gauge, а не counter
+	var active, rx, tx float64
+	for _, v := range other {
+		active += boolToFloat(v.Active)
+		rx += float64(v.Rxbytes)
+		tx += float64(v.Txbytes)
+	}
+	ch <- prometheus.MustNewConstMetric(hostsCollapsed, prometheus.GaugeValue, float64(len(other)))
+	if len(other) > 0 {
+		ch <- prometheus.MustNewConstMetric(hostActive, prometheus.GaugeValue, active, otherHost)
+		ch <- prometheus.MustNewConstMetric(hostsCollapsedRx, prometheus.GaugeValue, rx)
+		ch <- prometheus.MustNewConstMetric(hostsCollapsedTx, prometheus.GaugeValue, tx)
+	}
+}
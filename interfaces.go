@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// networkStat старое имя трафика интерфейсов, оставлено на время перехода дашбордов
+var networkStat = prometheus.NewDesc("keeneteus_network",
+	"Deprecated: use keeneteus_interface_receive_bytes_total and keeneteus_interface_transmit_bytes_total",
+	[]string{"interface", "rxtx"}, nil)
+
+type interfaceMetric struct {
+	desc *prometheus.Desc
+	typ  prometheus.ValueType
+	val  func(c *keenetic_api.InterfaceCounters) float64
+}
+
+func newInterfaceMetric(name, help string, typ prometheus.ValueType,
+	val func(c *keenetic_api.InterfaceCounters) float64) interfaceMetric {
+	return interfaceMetric{
+		desc: prometheus.NewDesc("keeneteus_interface_"+name, help, []string{"interface"}, nil),
+		typ:  typ,
+		val:  val,
+	}
+}
+
+var interfaceMetrics = []interfaceMetric{
+	newInterfaceMetric("receive_bytes_total", "Bytes received on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Rxbytes) }),
+	newInterfaceMetric("transmit_bytes_total", "Bytes sent on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Txbytes) }),
+	newInterfaceMetric("receive_packets_total", "Packets received on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Rxpackets) }),
+	newInterfaceMetric("transmit_packets_total", "Packets sent on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Txpackets) }),
+	newInterfaceMetric("receive_multicast_packets_total", "Multicast packets received on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.RxMulticastPackets) }),
+	newInterfaceMetric("transmit_multicast_packets_total", "Multicast packets sent on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.TxMulticastPackets) }),
+	newInterfaceMetric("receive_broadcast_packets_total", "Broadcast packets received on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.RxBroadcastPackets) }),
+	newInterfaceMetric("transmit_broadcast_packets_total", "Broadcast packets sent on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.TxBroadcastPackets) }),
+	newInterfaceMetric("receive_errors_total", "Receive errors on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Rxerrors) }),
+	newInterfaceMetric("transmit_errors_total", "Transmit errors on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Txerrors) }),
+	newInterfaceMetric("receive_dropped_total", "Received packets dropped on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Rxdropped) }),
+	newInterfaceMetric("transmit_dropped_total", "Outgoing packets dropped on the interface", prometheus.CounterValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Txdropped) }),
+	newInterfaceMetric("receive_speed_bits_per_second", "Current receive speed reported by the router", prometheus.GaugeValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Rxspeed) }),
+	newInterfaceMetric("transmit_speed_bits_per_second", "Current transmit speed reported by the router", prometheus.GaugeValue,
+		func(c *keenetic_api.InterfaceCounters) float64 { return float64(c.Txspeed) }),
+}
+
+// interfaceCollector exports show interface stat of the monitored interfaces
+type interfaceCollector struct {
+	snap *snapshot
+	// timestamps отдавать метрики со временем роутера
+	timestamps bool
+
+	// badTimestamp интерфейсы, о неразбираемом timestamp которых уже сообщили
+	mu           sync.Mutex
+	badTimestamp map[string]bool
+}
+
+func (c *interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, v := range interfaceMetrics {
+		ch <- v.desc
+	}
+	ch <- networkStat
+}
+
+func (c *interfaceCollector) Collect(ch chan<- prometheus.Metric) {
+	var counters = c.snap.getCounters()
+	var loc *time.Location
+	if c.timestamps {
+		loc = routerLocation(c.snap.getMetrics())
+	}
+	for k := range counters {
+		var t time.Time
+		if loc != nil {
+			var err error
+			t, err = parseRouterTime(counters[k].Timestamp, loc)
+			c.reportTimestamp(counters[k].InterfaceName, counters[k].Timestamp, err)
+		}
+		for _, v := range interfaceMetrics {
+			ch <- withTimestamp(c.timestamps, t,
+				prometheus.MustNewConstMetric(v.desc, v.typ, v.val(&counters[k]), counters[k].InterfaceName))
+		}
+		ch <- withTimestamp(c.timestamps, t, prometheus.MustNewConstMetric(networkStat, prometheus.GaugeValue,
+			float64(counters[k].Rxbytes), counters[k].InterfaceName, "rx"))
+		ch <- withTimestamp(c.timestamps, t, prometheus.MustNewConstMetric(networkStat, prometheus.GaugeValue,
+			float64(counters[k].Txbytes), counters[k].InterfaceName, "tx"))
+	}
+}
+
+// reportTimestamp logs an unparsable timestamp once until it parses again, Collect вызывается на каждый скрейп
+func (c *interfaceCollector) reportTimestamp(name, timestamp string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.badTimestamp, name)
+		return
+	}
+	if c.badTimestamp[name] {
+		return
+	}
+	if c.badTimestamp == nil {
+		c.badTimestamp = map[string]bool{}
+	}
+	c.badTimestamp[name] = true
+	slog.Warn("unparsable interface timestamp, exporting without it", "interface", name, "timestamp", timestamp, "err", err)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	internetUp = prometheus.NewDesc("keeneteus_internet_up",
+		"Whether the router considers the internet reachable",
+		nil, nil)
+	internetReliable = prometheus.NewDesc("keeneteus_internet_reliable",
+		"Whether the internet check result is reliable",
+		nil, nil)
+	internetCheck = prometheus.NewDesc("keeneteus_internet_check_accessible",
+		"Result of the router connectivity check by stage: gateway, dns, host, captive",
+		[]string{"check"}, nil)
+	internetGatewayFailures = prometheus.NewDesc("keeneteus_internet_gateway_failures_total",
+		"Failed checks of the default gateway",
+		[]string{"interface", "address"}, nil)
+	internetCaptiveFailures = prometheus.NewDesc("keeneteus_internet_captive_failures_total",
+		"Failed captive portal checks",
+		nil, nil)
+	internetHostAccessible = prometheus.NewDesc("keeneteus_internet_host_accessible",
+		"Whether the check host is accessible",
+		[]string{"host"}, nil)
+	internetHostResolved = prometheus.NewDesc("keeneteus_internet_host_resolved",
+		"Whether the check host name is resolved",
+		[]string{"host"}, nil)
+	internetHostFailures = prometheus.NewDesc("keeneteus_internet_check_failures_total",
+		"Failed connectivity checks per host",
+		[]string{"host"}, nil)
+)
+
+// internetCollector exports show internet status
+type internetCollector struct {
+	snap *snapshot
+}
+
+func (c *internetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- internetUp
+	ch <- internetReliable
+	ch <- internetCheck
+	ch <- internetGatewayFailures
+	ch <- internetCaptiveFailures
+	ch <- internetHostAccessible
+	ch <- internetHostResolved
+	ch <- internetHostFailures
+}
+
+func (c *internetCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil || !m.Show.Internet.Status.Enabled {
+		return
+	}
+	var st = &m.Show.Internet.Status
+
+	ch <- prometheus.MustNewConstMetric(internetUp, prometheus.GaugeValue, boolToFloat(st.Internet))
+	ch <- prometheus.MustNewConstMetric(internetReliable, prometheus.GaugeValue, boolToFloat(st.Reliable))
+	ch <- prometheus.MustNewConstMetric(internetCheck, prometheus.GaugeValue, boolToFloat(st.GatewayAccessible), "gateway")
+	ch <- prometheus.MustNewConstMetric(internetCheck, prometheus.GaugeValue, boolToFloat(st.DnsAccessible), "dns")
+	ch <- prometheus.MustNewConstMetric(internetCheck, prometheus.GaugeValue, boolToFloat(st.HostAccessible), "host")
+	ch <- prometheus.MustNewConstMetric(internetCheck, prometheus.GaugeValue, boolToFloat(st.CaptiveAccessible), "captive")
+	ch <- prometheus.MustNewConstMetric(internetGatewayFailures, prometheus.CounterValue, float64(st.Gateway.Failures),
+		st.Gateway.Interface, st.Gateway.Address)
+	ch <- prometheus.MustNewConstMetric(internetCaptiveFailures, prometheus.CounterValue, float64(st.Captive.Failures))
+
+	for host, v := range st.Hosts {
+		ch <- prometheus.MustNewConstMetric(internetHostAccessible, prometheus.GaugeValue, boolToFloat(v.Accessible), host)
+		ch <- prometheus.MustNewConstMetric(internetHostResolved, prometheus.GaugeValue, boolToFloat(v.Resolved), host)
+		ch <- prometheus.MustNewConstMetric(internetHostFailures, prometheus.CounterValue, float64(v.Failures), host)
+	}
+}
@@ -2,14 +2,20 @@ package keenetic_api
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -17,10 +23,16 @@ const (
 	loginPath     = "/login"
 	dashboardPath = "/dashboard"
 	rciPath       = "/rci/"
+
+	// requestTimeout ограничивает запрос к роутеру вместе с чтением ответа
+	requestTimeout = 30 * time.Second
 )
 
 var errBadCode = errors.New("keenetic return bad status code")
 
+// ErrUnauthorized сессия на роутере истекла, требуется повторный Auth
+var ErrUnauthorized = errors.New("keenetic session is not authorized")
+
 type StatRQ interface {
 	GetRqBody() io.Reader
 	Unmarshal(b io.Reader) error
@@ -29,63 +41,131 @@ type StatRQ interface {
 type api struct {
 	endpoint string
 	login    string
-	password string
+	// password источник пароля, вызывается только на время doAuth
+	password func() ([]byte, error)
 
 	cl http.Client
 
+	tls      *tls.Config
+	pins     []string
+	insecure bool
+
+	session *sessionStore
+
+	name     string
+	log      *slog.Logger
+	observer Observer
+
+	sem      chan struct{}
+	limiter  *tokenBucket
+	flightMu sync.Mutex
+	flights  map[string]*flight
+
+	authorized atomic.Bool
+
+	// authMu защищает challenge, realm и cookie: Auth и Logout меняют их во время запросов RCI
+	authMu       sync.Mutex
 	ndmChallenge string
 	ndmRealm     string
 	cookie       []*http.Cookie
 }
 
-func NewApi(endpoint string, login string, password string) *api {
-	return &api{
+func NewApi(endpoint string, login string, password string, opts ...Option) *api {
+	var a = &api{
 		endpoint: endpoint,
 		login:    login,
-		password: password,
-		cl:       http.Client{},
+		password: func() ([]byte, error) { return []byte(password), nil },
+		cl:       http.Client{Timeout: requestTimeout},
+	}
+
+	for _, o := range opts {
+		o(a)
+	}
+	a.applyTLS()
+
+	if a.name == "" {
+		a.name = routerName(endpoint)
 	}
+	if a.log == nil {
+		a.log = discardLogger()
+	}
+	a.log = a.log.With("router", a.name)
+
+	return a
 }
 
 // Auth Авторизация в keenetic, Требуется выполнить перед получением метрик
 func (a *api) Auth() error {
 	var err error
-	if err = a.getAuth(); err != nil {
+	var ok bool
+	if a.session != nil && a.loadSession() == nil {
+		if ok, err = a.getAuth(); err != nil {
+			return err
+		}
+		if ok {
+			a.log.Info("restored saved session")
+			a.authorized.Store(true)
+			return nil
+		}
+		// Challenge уже получен проверкой сессии
+		a.log.Info("saved session expired")
+		a.dropSession()
+	} else if _, err = a.getAuth(); err != nil {
 		return err
 	}
 
-	if err = a.doAuth(); err != nil {
-		return err
+	a.authMu.Lock()
+	a.log.Info("authorizing", "login", a.login, "realm", a.ndmRealm, "challenge", redacted(a.ndmChallenge))
+	a.authMu.Unlock()
+	var start = time.Now()
+	if err = a.doAuth(); err == nil {
+		// Роутер принял логин, но сессия не авторизована
+		if ok, err = a.getAuth(); err == nil && !ok {
+			err = ErrUnauthorized
+		}
 	}
-
-	if err = a.getAuth(); err != nil {
+	if a.observer != nil {
+		a.observer.ObserveAuth(a.name, time.Since(start), err)
+	}
+	if err != nil {
+		a.log.Error("authorization failed", "login", a.login, "err", err)
 		return err
 	}
+	a.log.Info("authorized", "login", a.login)
+	a.authorized.Store(true)
+
+	// Не удалось сохранить сессию - не страшно, авторизуемся заново при следующем запуске
+	if a.session != nil {
+		if err = a.saveSession(); err != nil {
+			a.log.Warn("can't save session", "err", err)
+		}
+	}
 
 	return nil
 }
 
-// getAuth проверка авторизации у keenetic
-func (a *api) getAuth() error {
+// getAuth проверка авторизации у keenetic, true - сессия уже авторизована
+func (a *api) getAuth() (bool, error) {
 	var err error
 	var rq *http.Request
 	if rq, err = http.NewRequest(http.MethodGet, a.endpoint+authPath, nil); err != nil {
-		return err
+		return false, err
 	}
 
-	for i := range a.cookie {
-		rq.AddCookie(a.cookie[i])
-	}
+	a.addCookies(rq)
 
 	rq.Header.Set("Accept", "application/json, text/plain, */*")
 	rq.Header.Set("Referer", a.endpoint+loginPath)
 	rq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.54 Safari/537.36")
 
 	var rs *http.Response
-	if rs, err = a.cl.Do(rq); err != nil {
-		return err
+	if rs, err = a.do(rq); err != nil {
+		return false, err
 	}
+	defer rs.Body.Close()
 
+	a.authMu.Lock()
+	defer a.authMu.Unlock()
 	switch rs.StatusCode {
 	case http.StatusOK:
 		a.cookie = append(a.cookie, &http.Cookie{
@@ -99,15 +179,15 @@ func (a *api) getAuth() error {
 			Path:  "/",
 			Raw:   "sysmode=router; Path=/",
 		})
-		return nil
+		return true, nil
 	case http.StatusUnauthorized:
 		a.ndmChallenge = rs.Header.Get("X-NDM-Challenge")
 		a.ndmRealm = rs.Header.Get("X-NDM-Realm")
 		a.cookie = rs.Cookies()
-		return nil
+		return false, nil
 	}
 
-	return errBadCode
+	return false, errBadCode
 }
 
 type AuthJson struct {
@@ -117,11 +197,25 @@ type AuthJson struct {
 
 // getAuth авторизация в keenetic
 func (a *api) doAuth() error {
+	var err error
+	var password []byte
+	if password, err = a.password(); err != nil {
+		return err
+	}
+
+	a.authMu.Lock()
+	var realm, challenge = a.ndmRealm, a.ndmChallenge
+	a.authMu.Unlock()
+
 	var m5 = md5.New()
-	m5.Write([]byte(a.login + ":" + a.ndmRealm + ":" + a.password))
+	m5.Write([]byte(a.login + ":" + realm + ":"))
+	m5.Write(password)
+	for k := range password {
+		password[k] = 0
+	}
 
 	var sh256 = sha256.New()
-	sh256.Write([]byte(a.ndmChallenge))
+	sh256.Write([]byte(challenge))
 	sh256.Write([]byte(hex.EncodeToString(m5.Sum(nil))))
 	sh256.Sum(nil)
 
@@ -137,15 +231,12 @@ func (a *api) doAuth() error {
 		Password: s.String(),
 	})
 
-	var err error
 	var rq *http.Request
 	if rq, err = http.NewRequest(http.MethodPost, a.endpoint+authPath, b); err != nil {
 		return err
 	}
 
-	for i := range a.cookie {
-		rq.AddCookie(a.cookie[i])
-	}
+	a.addCookies(rq)
 
 	rq.Header.Set("Accept", "application/json, text/plain, */*")
 	rq.Header.Set("Content-Type", "application/json;charset=UTF-8")
@@ -154,9 +245,10 @@ func (a *api) doAuth() error {
 	rq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.54 Safari/537.36")
 
 	var rs *http.Response
-	if rs, err = a.cl.Do(rq); err != nil {
+	if rs, err = a.do(rq); err != nil {
 		return err
 	}
+	defer rs.Body.Close()
 
 	if rs.StatusCode != http.StatusOK {
 		return errBadCode
@@ -165,16 +257,130 @@ func (a *api) doAuth() error {
 	return nil
 }
 
+// Authorized true после успешного Auth и до ответа 401 или Logout
+func (a *api) Authorized() bool {
+	return a.authorized.Load()
+}
+
+// Metric выполняет запрос RCI, одинаковые одновременные запросы объединяются в один
 func (a *api) Metric(q StatRQ) error {
+	return a.MetricContext(context.Background(), q)
+}
+
+// MetricContext is Metric that stops waiting when ctx is done. The request itself may be shared
+// with identical calls, so it keeps running and is bounded by the client timeout instead.
+func (a *api) MetricContext(ctx context.Context, q StatRQ) error {
 	var err error
-	var rq *http.Request
-	if rq, err = http.NewRequest(http.MethodPost, a.endpoint+rciPath, q.GetRqBody()); err != nil {
+	var body []byte
+	if body, err = io.ReadAll(q.GetRqBody()); err != nil {
 		return err
 	}
 
+	// Общий запрос не отменяется вместе с первым вызовом, его ограничивает таймаут клиента
+	var shared = context.WithoutCancel(ctx)
+	var data []byte
+	if data, err = a.coalesce(ctx, string(body), func() ([]byte, error) { return a.rci(shared, body) }); err != nil {
+		return err
+	}
+
+	return q.Unmarshal(bytes.NewReader(data))
+}
+
+func (a *api) rci(ctx context.Context, body []byte) ([]byte, error) {
+	var err error
+	var rq *http.Request
+	if rq, err = http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+rciPath, bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+
+	a.addCookies(rq)
+
+	rq.Header.Set("Accept", "application/json, text/plain, */*")
+	rq.Header.Set("Origin", a.endpoint)
+	rq.Header.Set("Referer", a.endpoint+dashboardPath)
+	rq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.54 Safari/537.36")
+
+	var rs *http.Response
+	if rs, err = a.do(rq); err != nil {
+		return nil, err
+	}
+	defer rs.Body.Close()
+
+	if rs.StatusCode == http.StatusUnauthorized {
+		a.log.Warn("session is not authorized")
+		a.authorized.Store(false)
+		a.dropSession()
+		return nil, ErrUnauthorized
+	}
+	if rs.StatusCode != http.StatusOK {
+		return nil, errBadCode
+	}
+
+	return io.ReadAll(rs.Body)
+}
+
+// addCookies подставляет cookie текущей сессии в запрос
+func (a *api) addCookies(rq *http.Request) {
+	a.authMu.Lock()
+	defer a.authMu.Unlock()
 	for i := range a.cookie {
 		rq.AddCookie(a.cookie[i])
 	}
+}
+
+// do выполняет запрос к роутеру с учетом ограничений и пишет в лог путь, статус и длительность
+func (a *api) do(rq *http.Request) (*http.Response, error) {
+	var release, err = a.acquire(rq.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var start = time.Now()
+	var rs *http.Response
+	rs, err = a.cl.Do(rq)
+	var took = time.Since(start)
+
+	if err != nil {
+		release()
+		a.log.Error("request failed", "method", rq.Method, "path", rq.URL.Path, "duration", took, "err", err)
+		if a.observer != nil {
+			a.observer.ObserveRequest(a.name, rq.Method, rq.URL.Path, 0, took, 0)
+		}
+		return nil, err
+	}
+
+	var level = slog.LevelDebug
+	if took > slowRequest {
+		level = slog.LevelWarn
+	}
+	a.log.Log(rq.Context(), level, "request", "method", rq.Method, "path", rq.URL.Path,
+		"status", rs.StatusCode, "duration", took, "size", rs.ContentLength)
+
+	// Слот освобождается только после чтения тела, пока роутер его отдает он занят
+	rs.Body = &trackedBody{ReadCloser: rs.Body, done: func(size int64) {
+		release()
+		if a.observer != nil {
+			a.observer.ObserveRequest(a.name, rq.Method, rq.URL.Path, rs.StatusCode, time.Since(start), size)
+		}
+	}}
+
+	return rs, nil
+}
+
+// Logout завершает сессию на роутере, сохраненная сессия тоже удаляется
+func (a *api) Logout() error {
+	return a.LogoutContext(context.Background())
+}
+
+// LogoutContext is Logout bounded by ctx
+func (a *api) LogoutContext(ctx context.Context) error {
+	var err error
+	var rq *http.Request
+	if rq, err = http.NewRequestWithContext(ctx, http.MethodDelete, a.endpoint+authPath, nil); err != nil {
+		return err
+	}
+
+	a.addCookies(rq)
 
 	rq.Header.Set("Accept", "application/json, text/plain, */*")
 	rq.Header.Set("Origin", a.endpoint)
@@ -182,18 +388,20 @@ func (a *api) Metric(q StatRQ) error {
 	rq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.54 Safari/537.36")
 
 	var rs *http.Response
-	if rs, err = a.cl.Do(rq); err != nil {
+	if rs, err = a.do(rq); err != nil {
 		return err
 	}
+	defer rs.Body.Close()
+
+	a.authMu.Lock()
+	a.cookie = nil
+	a.authMu.Unlock()
+	a.authorized.Store(false)
+	a.dropSession()
 
 	if rs.StatusCode != http.StatusOK {
 		return errBadCode
 	}
-	defer rs.Body.Close()
-
-	if err = q.Unmarshal(rs.Body); err != nil {
-		return err
-	}
 
 	return nil
 }
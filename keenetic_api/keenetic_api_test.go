@@ -0,0 +1,57 @@
+package keenetic_api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeAuth роутер, принимающий POST /auth и авторизующий сессию, если accept
+func fakeAuth(accept bool) http.HandlerFunc {
+	var authorized bool
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != authPath {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if authorized {
+				return
+			}
+			w.Header().Set("X-NDM-Challenge", "challenge")
+			w.Header().Set("X-NDM-Realm", "realm")
+			http.SetCookie(w, &http.Cookie{Name: "ABCDEF", Value: "session"})
+			w.WriteHeader(http.StatusUnauthorized)
+		case http.MethodPost:
+			authorized = accept
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	var tests = []struct {
+		name    string
+		accept  bool
+		wantErr error
+	}{
+		{"accepted", true, nil},
+		{"rejected", false, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var srv = httptest.NewServer(fakeAuth(tt.accept))
+			defer srv.Close()
+
+			var a = NewApi(srv.URL, "admin", "secret")
+			if err := a.Auth(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Auth() = %v, want %v", err, tt.wantErr)
+			}
+			if a.Authorized() != tt.accept {
+				t.Errorf("Authorized() = %v, want %v", a.Authorized(), tt.accept)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package keenetic_api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithMaxConcurrent allow at most n simultaneous requests to the router
+func WithMaxConcurrent(n int) Option {
+	return func(a *api) {
+		if n > 0 {
+			a.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRateLimit allow on average rps requests per second to the router with bursts up to burst
+func WithRateLimit(rps float64, burst int) Option {
+	return func(a *api) {
+		if rps > 0 {
+			if burst < 1 {
+				burst = 1
+			}
+			a.limiter = &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+		}
+	}
+}
+
+// tokenBucket простой token bucket, роутеры слабые и не должны тормозить от экспортера
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		var now = time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		var wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		var t = time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// acquire занимает слот семафора и токен, release нужно вызвать после чтения ответа
+func (a *api) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if a.sem != nil {
+		select {
+		case a.sem <- struct{}{}:
+			release = func() { <-a.sem }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if a.limiter != nil {
+		if err = a.limiter.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// flight запрос RCI, ответ которого получат все одинаковые запросы, пришедшие пока он выполняется
+type flight struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// coalesce выполняет f один раз для всех одновременных вызовов с тем же key.
+// f выполняется в отдельной горутине и не должен зависеть от ctx какого-либо вызова:
+// каждый вызов, включая первый, перестает ждать только по своему ctx.
+func (a *api) coalesce(ctx context.Context, key string, f func() ([]byte, error)) ([]byte, error) {
+	a.flightMu.Lock()
+	var fl, ok = a.flights[key]
+	if !ok {
+		if a.flights == nil {
+			a.flights = map[string]*flight{}
+		}
+		fl = &flight{done: make(chan struct{})}
+		a.flights[key] = fl
+		go func() {
+			fl.data, fl.err = f()
+
+			a.flightMu.Lock()
+			delete(a.flights, key)
+			a.flightMu.Unlock()
+			close(fl.done)
+		}()
+	}
+	a.flightMu.Unlock()
+
+	select {
+	case <-fl.done:
+		return fl.data, fl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package keenetic_api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var b = &tokenBucket{rate: 20, burst: 2, tokens: 2, last: time.Now()}
+	var ctx = context.Background()
+
+	var start = time.Now()
+	for k := 0; k < 3; k++ {
+		if err := b.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Два токена из burst сразу, третий через 1/rate
+	if took := time.Since(start); took < 40*time.Millisecond {
+		t.Errorf("three tokens took %v, want about 50ms", took)
+	}
+
+	var cctx, cancel = context.WithCancel(ctx)
+	cancel()
+	b.tokens = 0
+	if err := b.wait(cctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait on cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	var a = NewApi("http://192.168.1.1", "admin", "", WithMaxConcurrent(1))
+
+	var release, err = a.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = a.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire over the limit = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	release()
+	if release, err = a.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestCoalesce(t *testing.T) {
+	var a = NewApi("http://192.168.1.1", "admin", "")
+	var calls, started atomic.Int32
+	var unblock = make(chan struct{})
+	var f = func() ([]byte, error) {
+		calls.Add(1)
+		<-unblock
+		return []byte("data"), nil
+	}
+
+	var wg sync.WaitGroup
+	var results = make([]string, 5)
+	for k := range results {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			started.Add(1)
+			var data, err = a.coalesce(context.Background(), "q", f)
+			if err != nil {
+				t.Error(err)
+			}
+			results[k] = string(data)
+		}(k)
+	}
+
+	// Ждем, пока запрос начнется, и проверяем, что ожидающего можно прервать
+	for calls.Load() == 0 || started.Load() < int32(len(results)) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := a.coalesce(ctx, "q", f); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled waiter = %v, want %v", err, context.Canceled)
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("f called %d times, want 1", n)
+	}
+	for k, v := range results {
+		if v != "data" {
+			t.Errorf("result %d = %q, want %q", k, v, "data")
+		}
+	}
+}
+
+func TestCoalesceLeaderCancel(t *testing.T) {
+	var a = NewApi("http://192.168.1.1", "admin", "")
+	var calls atomic.Int32
+	var running = make(chan struct{}, 1)
+	var unblock = make(chan struct{})
+	var f = func() ([]byte, error) {
+		calls.Add(1)
+		running <- struct{}{}
+		<-unblock
+		return []byte("data"), nil
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var leader = make(chan error, 1)
+	go func() {
+		var _, err = a.coalesce(ctx, "q", f)
+		leader <- err
+	}()
+	<-running
+
+	var waiter = make(chan []byte, 1)
+	go func() {
+		var data, err = a.coalesce(context.Background(), "q", f)
+		if err != nil {
+			t.Error(err)
+		}
+		waiter <- data
+	}()
+
+	// Первый вызов уходит, общий запрос продолжается
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled leader = %v, want %v", err, context.Canceled)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(unblock)
+	if data := <-waiter; string(data) != "data" {
+		t.Errorf("waiter got %q, want %q", data, "data")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("f called %d times, want 1", n)
+	}
+}
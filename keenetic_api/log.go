@@ -0,0 +1,48 @@
+package keenetic_api
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// slowRequest запросы дольше этого пишутся в лог как warn
+const slowRequest = 2 * time.Second
+
+// redacted скрывает значение атрибута, но показывает, что оно было
+type redacted string
+
+func (r redacted) LogValue() slog.Value {
+	if r == "" {
+		return slog.StringValue("")
+	}
+	return slog.StringValue("[REDACTED]")
+}
+
+// WithLogger log requests and authorization through l.
+// Пароль, хэши и cookie в лог не попадают.
+func WithLogger(l *slog.Logger) Option {
+	return func(a *api) {
+		a.log = l
+	}
+}
+
+// WithName name of the router added to every log record, by default the endpoint host
+func WithName(name string) Option {
+	return func(a *api) {
+		a.name = name
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// routerName имя роутера по умолчанию - хост из endpoint
+func routerName(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return endpoint
+}
@@ -0,0 +1,113 @@
+package keenetic_api
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer receives measurements of every call to the router
+type Observer interface {
+	// ObserveRequest status 0 - запрос не дошел до роутера, size - прочитанный размер тела ответа
+	ObserveRequest(router, method, path string, status int, duration time.Duration, size int64)
+	ObserveAuth(router string, duration time.Duration, err error)
+}
+
+// WithObserver report every request and authorization to o
+func WithObserver(o Observer) Option {
+	return func(a *api) {
+		a.observer = o
+	}
+}
+
+// PrometheusObserver Observer, exporting client metrics to Prometheus
+type PrometheusObserver struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	size         *prometheus.HistogramVec
+	auth         *prometheus.CounterVec
+	authDuration *prometheus.HistogramVec
+}
+
+// NewPrometheusObserver creates the client metrics and registers them in reg, if it is not nil
+func NewPrometheusObserver(reg prometheus.Registerer) (*PrometheusObserver, error) {
+	var o = &PrometheusObserver{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keenetic_api_requests_total",
+			Help: "Requests to the router by path and status, status 0 - the request failed",
+		}, []string{"router", "method", "path", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "keenetic_api_request_duration_seconds",
+			Help:    "Time until the router response is read",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2, 5, 10},
+		}, []string{"router", "method", "path"}),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "keenetic_api_response_size_bytes",
+			Help:    "Size of the router response body",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"router", "method", "path"}),
+		auth: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keenetic_api_auth_total",
+			Help: "Authorization handshakes by result: success, rejected or error",
+		}, []string{"router", "result"}),
+		authDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "keenetic_api_auth_duration_seconds",
+			Help:    "Duration of the authorization handshake",
+			Buckets: []float64{.1, .25, .5, 1, 2, 5, 10},
+		}, []string{"router"}),
+	}
+
+	if reg != nil {
+		for _, c := range []prometheus.Collector{o.requests, o.duration, o.size, o.auth, o.authDuration} {
+			if err := reg.Register(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+func (o *PrometheusObserver) ObserveRequest(router, method, path string, status int, duration time.Duration, size int64) {
+	o.requests.WithLabelValues(router, method, path, strconv.Itoa(status)).Inc()
+	o.duration.WithLabelValues(router, method, path).Observe(duration.Seconds())
+	if status != 0 {
+		o.size.WithLabelValues(router, method, path).Observe(float64(size))
+	}
+}
+
+func (o *PrometheusObserver) ObserveAuth(router string, duration time.Duration, err error) {
+	var result = "success"
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		result = "rejected"
+	case err != nil:
+		result = "error"
+	}
+	o.auth.WithLabelValues(router, result).Inc()
+	o.authDuration.WithLabelValues(router).Observe(duration.Seconds())
+}
+
+// trackedBody считает прочитанные байты и вызывает done при Close
+type trackedBody struct {
+	io.ReadCloser
+	done func(size int64)
+	size int64
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	var n, err = b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	if b.done != nil {
+		b.done(b.size)
+		b.done = nil
+	}
+	return b.ReadCloser.Close()
+}
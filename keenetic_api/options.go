@@ -0,0 +1,110 @@
+package keenetic_api
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+var errPinMismatch = errors.New("keenetic certificate does not match any pinned key")
+
+// Option настройка клиента, передается в NewApi
+type Option func(a *api)
+
+// WithPasswordFunc take the password from f on every authorization instead of keeping it in the client.
+// Возвращенный срез затирается после вычисления хэша.
+func WithPasswordFunc(f func() ([]byte, error)) Option {
+	return func(a *api) {
+		a.password = f
+	}
+}
+
+// WithRootCAs verify the router certificate against the given pool instead of the system roots
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(a *api) {
+		a.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithSPKIPins accept only certificates whose SubjectPublicKeyInfo SHA-256 (base64) is in pins.
+// Пин заменяет проверку цепочки, если не задан WithRootCAs, так можно подключиться к самоподписанному сертификату.
+func WithSPKIPins(pins ...string) Option {
+	return func(a *api) {
+		a.pins = append(a.pins, pins...)
+	}
+}
+
+// WithInsecureSkipVerify disable any verification of the router certificate
+func WithInsecureSkipVerify() Option {
+	return func(a *api) {
+		a.tlsConfig().InsecureSkipVerify = true
+		a.insecure = true
+	}
+}
+
+// WithServerName override SNI and the name the certificate is verified against
+func WithServerName(name string) Option {
+	return func(a *api) {
+		a.tlsConfig().ServerName = name
+	}
+}
+
+func (a *api) tlsConfig() *tls.Config {
+	if a.tls == nil {
+		a.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return a.tls
+}
+
+// applyTLS собирает транспорт клиента после применения всех опций
+func (a *api) applyTLS() {
+	if a.tls == nil && len(a.pins) == 0 {
+		return
+	}
+
+	var c = a.tlsConfig()
+	if len(a.pins) > 0 {
+		// Цепочку проверяем сами в VerifyConnection, чтобы пин работал и без CA
+		c.InsecureSkipVerify = true
+		c.VerifyConnection = a.verifyConnection
+	}
+
+	var t = http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = c
+	a.cl.Transport = t
+}
+
+func (a *api) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errPinMismatch
+	}
+
+	if a.tls.RootCAs != nil && !a.insecure {
+		var opts = x509.VerifyOptions{
+			Roots:         a.tls.RootCAs,
+			DNSName:       cs.ServerName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+			return err
+		}
+	}
+
+	for _, cert := range cs.PeerCertificates {
+		var sum = sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		var pin = base64.StdEncoding.EncodeToString(sum[:])
+		for _, v := range a.pins {
+			if v == pin {
+				return nil
+			}
+		}
+	}
+
+	return errPinMismatch
+}
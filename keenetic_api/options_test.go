@@ -0,0 +1,61 @@
+package keenetic_api
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyConnection(t *testing.T) {
+	var srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var cert = srv.Certificate()
+	var sum = sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	var pin = base64.StdEncoding.EncodeToString(sum[:])
+
+	var trusted = x509.NewCertPool()
+	trusted.AddCert(cert)
+
+	var tests = []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{"pin without ca", []Option{WithSPKIPins("other", pin)}, nil},
+		{"pin mismatch", []Option{WithSPKIPins("other")}, errPinMismatch},
+		{"pin with ca", []Option{WithRootCAs(trusted), WithServerName("example.com"), WithSPKIPins(pin)}, nil},
+		{"pin with foreign ca", []Option{WithRootCAs(x509.NewCertPool()), WithServerName("example.com"), WithSPKIPins(pin)}, x509.UnknownAuthorityError{}},
+		{"pin mismatch with ca", []Option{WithRootCAs(trusted), WithServerName("example.com"), WithSPKIPins("other")}, errPinMismatch},
+		{"pin with insecure", []Option{WithInsecureSkipVerify(), WithRootCAs(x509.NewCertPool()), WithSPKIPins(pin)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a = NewApi(srv.URL, "admin", "", tt.opts...)
+			var rs, err = a.cl.Get(srv.URL)
+			if err == nil {
+				rs.Body.Close()
+			}
+
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			case x509.UnknownAuthorityError:
+				if !errors.As(err, &want) {
+					t.Errorf("error = %v, want unknown authority", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
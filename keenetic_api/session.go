@@ -0,0 +1,114 @@
+package keenetic_api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+)
+
+var errBadSession = errors.New("keenetic session file is corrupted")
+
+// sessionStore зашифрованный файл с cookie сессии
+type sessionStore struct {
+	path string
+	key  [32]byte
+}
+
+type sessionData struct {
+	Endpoint string         `json:"endpoint"`
+	Realm    string         `json:"realm"`
+	Cookies  []*http.Cookie `json:"cookies"`
+}
+
+// WithSessionFile keep session cookies in path encrypted with AES-256-GCM.
+// key must be 32 random bytes (e.g. openssl rand -base64 32), not a password: it is used as is.
+// Сессия переживает перезапуск, повторная авторизация нужна только если роутер ответил 401.
+func WithSessionFile(path string, key [32]byte) Option {
+	return func(a *api) {
+		a.session = &sessionStore{path: path, key: key}
+	}
+}
+
+func (s *sessionStore) aead() (cipher.AEAD, error) {
+	var b, err = aes.NewCipher(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
+
+// loadSession восстанавливает cookie из файла
+func (a *api) loadSession() error {
+	var err error
+	var data []byte
+	if data, err = os.ReadFile(a.session.path); err != nil {
+		return err
+	}
+
+	var gcm cipher.AEAD
+	if gcm, err = a.session.aead(); err != nil {
+		return err
+	}
+	if len(data) < gcm.NonceSize() {
+		return errBadSession
+	}
+
+	if data, err = gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil); err != nil {
+		return err
+	}
+
+	var sd sessionData
+	if err = json.Unmarshal(data, &sd); err != nil {
+		return err
+	}
+	if sd.Endpoint != a.endpoint {
+		return errBadSession
+	}
+
+	a.authMu.Lock()
+	a.ndmRealm = sd.Realm
+	a.cookie = sd.Cookies
+	a.authMu.Unlock()
+	return nil
+}
+
+// saveSession сохраняет cookie сессии, служебные cookie из getAuth не сохраняются
+func (a *api) saveSession() error {
+	a.authMu.Lock()
+	var sd = sessionData{Endpoint: a.endpoint, Realm: a.ndmRealm}
+	for _, c := range a.cookie {
+		if c.Name != "_authorized" && c.Name != "sysmode" {
+			sd.Cookies = append(sd.Cookies, c)
+		}
+	}
+	a.authMu.Unlock()
+
+	var err error
+	var data []byte
+	if data, err = json.Marshal(&sd); err != nil {
+		return err
+	}
+
+	var gcm cipher.AEAD
+	if gcm, err = a.session.aead(); err != nil {
+		return err
+	}
+	var nonce = make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	return os.WriteFile(a.session.path, gcm.Seal(nonce, nonce, data, nil), 0600)
+}
+
+// dropSession удаляет файл сессии, если он есть
+func (a *api) dropSession() {
+	if a.session != nil {
+		_ = os.Remove(a.session.path)
+	}
+}
@@ -0,0 +1,57 @@
+package keenetic_api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "session")
+	var key = [32]byte{1, 2, 3}
+
+	var a = NewApi("https://192.168.1.1", "admin", "", WithSessionFile(path, key))
+	a.ndmRealm = "Keenetic Giga"
+	a.cookie = []*http.Cookie{
+		{Name: "ABCDEF", Value: "session"},
+		{Name: "_authorized", Value: "admin"},
+		{Name: "sysmode", Value: "router"},
+	}
+	if err := a.saveSession(); err != nil {
+		t.Fatal(err)
+	}
+
+	var fi, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+
+	var b = NewApi("https://192.168.1.1", "admin", "", WithSessionFile(path, key))
+	if err = b.loadSession(); err != nil {
+		t.Fatal(err)
+	}
+	if b.ndmRealm != a.ndmRealm {
+		t.Errorf("realm = %q, want %q", b.ndmRealm, a.ndmRealm)
+	}
+	if want := []*http.Cookie{{Name: "ABCDEF", Value: "session"}}; !reflect.DeepEqual(b.cookie, want) {
+		t.Errorf("cookies = %v, want %v", b.cookie, want)
+	}
+
+	if err = NewApi("https://192.168.1.1", "admin", "", WithSessionFile(path, [32]byte{4})).loadSession(); err == nil {
+		t.Error("loaded session with a wrong key")
+	}
+	if err = NewApi("https://192.168.1.2", "admin", "", WithSessionFile(path, key)).loadSession(); !errors.Is(err, errBadSession) {
+		t.Errorf("other endpoint: error = %v, want %v", err, errBadSession)
+	}
+
+	b.dropSession()
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("session file not removed: %v", err)
+	}
+}
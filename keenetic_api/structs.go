@@ -4,50 +4,24 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"strconv"
 )
 
 type InterfaceStat struct {
 	Devices       []Eth  `json:"-"`
 	Interfaces    []Eth  `json:"-"`
 	InterfacesStr string `json:"-"`
+	// Detail уровень детализации графика ip hotspot chart
+	Detail int `json:"-"`
 
 	Show struct {
 		Interface struct {
-			Stat []struct {
-				InterfaceName      string `json:"-"`
-				Rxpackets          int    `json:"rxpackets"`
-				RxMulticastPackets int    `json:"rx-multicast-packets"`
-				RxBroadcastPackets int    `json:"rx-broadcast-packets"`
-				Rxbytes            int64  `json:"rxbytes"`
-				Rxerrors           int    `json:"rxerrors"`
-				Rxdropped          int    `json:"rxdropped"`
-				Txpackets          int    `json:"txpackets"`
-				TxMulticastPackets int    `json:"tx-multicast-packets"`
-				TxBroadcastPackets int    `json:"tx-broadcast-packets"`
-				Txbytes            int64  `json:"txbytes"`
-				Txerrors           int    `json:"txerrors"`
-				Txdropped          int    `json:"txdropped"`
-				Timestamp          string `json:"timestamp"`
-				LastOverflow       string `json:"last-overflow"`
-				Rxspeed            int    `json:"rxspeed"`
-				Txspeed            int    `json:"txspeed"`
-			} `json:"stat"`
+			Stat []InterfaceCounters `json:"stat"`
 		} `json:"interface"`
 		Ip struct {
 			Hotspot struct {
 				Chart struct {
-					Bar []struct {
-						Mac  string `json:"mac,omitempty"`
-						Bars []struct {
-							Attribute string `json:"attribute"`
-							Data      []struct {
-								T int `json:"t"`
-								V int `json:"v"`
-							} `json:"data"`
-						} `json:"bars"`
-						Multicast bool `json:"multicast,omitempty"`
-						Others    bool `json:"others,omitempty"`
-					} `json:"bar"`
+					Bar []ChartBar `json:"bar"`
 				} `json:"chart"`
 			} `json:"hotspot"`
 		} `json:"ip"`
@@ -66,7 +40,7 @@ func (i *InterfaceStat) GetRqBody() io.Reader {
 			i.InterfacesStr += i.Devices[k].Code + `,`
 		}
 		i.InterfacesStr = i.InterfacesStr[:len(i.InterfacesStr)-1]
-		i.InterfacesStr += `","detail":0,"attributes":"rxbytes,txbytes"}}}}}`
+		i.InterfacesStr += `","detail":` + strconv.Itoa(i.Detail) + `,"attributes":"rxbytes,txbytes"}}}}}`
 	}
 	return bytes.NewBufferString(i.InterfacesStr)
 }
@@ -81,6 +55,46 @@ func (i *InterfaceStat) Unmarshal(b io.Reader) error {
 	return nil
 }
 
+// ChartBar traffic chart of one device
+type ChartBar struct {
+	Mac       string        `json:"mac,omitempty"`
+	Bars      []ChartSeries `json:"bars"`
+	Multicast bool          `json:"multicast,omitempty"`
+	Others    bool          `json:"others,omitempty"`
+}
+
+// ChartSeries time series of one attribute, e.g. rxbytes
+type ChartSeries struct {
+	Attribute string       `json:"attribute"`
+	Data      []ChartPoint `json:"data"`
+}
+
+// ChartPoint bucket of the chart, T - unix time of the bucket, V - value in the bucket
+type ChartPoint struct {
+	T int64 `json:"t"`
+	V int64 `json:"v"`
+}
+
+type InterfaceCounters struct {
+	InterfaceName      string `json:"-"`
+	Rxpackets          int    `json:"rxpackets"`
+	RxMulticastPackets int    `json:"rx-multicast-packets"`
+	RxBroadcastPackets int    `json:"rx-broadcast-packets"`
+	Rxbytes            int64  `json:"rxbytes"`
+	Rxerrors           int    `json:"rxerrors"`
+	Rxdropped          int    `json:"rxdropped"`
+	Txpackets          int    `json:"txpackets"`
+	TxMulticastPackets int    `json:"tx-multicast-packets"`
+	TxBroadcastPackets int    `json:"tx-broadcast-packets"`
+	Txbytes            int64  `json:"txbytes"`
+	Txerrors           int    `json:"txerrors"`
+	Txdropped          int    `json:"txdropped"`
+	Timestamp          string `json:"timestamp"`
+	LastOverflow       string `json:"last-overflow"`
+	Rxspeed            int    `json:"rxspeed"`
+	Txspeed            int    `json:"txspeed"`
+}
+
 type Eth struct {
 	Name string
 	Code string
@@ -103,6 +117,12 @@ func (i *InterfaceStat) SetDevices(interfaces []Eth) {
 	i.Devices = interfaces
 }
 
+// SetDetail Set detail level of the devices traffic chart
+func (i *InterfaceStat) SetDetail(detail int) {
+	i.Detail = detail
+	i.InterfacesStr = ""
+}
+
 func (i *InterfaceStat) GetDeviceName(k int) string {
 	if len(i.Devices) > k {
 		return i.Devices[k].Name
@@ -111,6 +131,9 @@ func (i *InterfaceStat) GetDeviceName(k int) string {
 }
 
 type Metrics struct {
+	// Interfaces все интерфейсы роутера по id, заполняется в Unmarshal
+	Interfaces map[string]Interface `json:"-"`
+
 	Whoami struct {
 		User  string `json:"user"`
 		Agent string `json:"agent"`
@@ -161,27 +184,7 @@ type Metrics struct {
 			Swapfree   int    `json:"swapfree"`
 			Uptime     string `json:"uptime"`
 		} `json:"system"`
-		Media struct {
-			Media0 struct {
-				Usb struct {
-					Port    int    `json:"port"`
-					Version string `json:"version"`
-				} `json:"usb"`
-				State        string `json:"state"`
-				Manufacturer string `json:"manufacturer"`
-				Product      string `json:"product"`
-				Serial       string `json:"serial"`
-				Size         string `json:"size"`
-				Partition    []struct {
-					Uuid   string `json:"uuid"`
-					Label  string `json:"label"`
-					Fstype string `json:"fstype"`
-					State  string `json:"state"`
-					Total  string `json:"total"`
-					Free   string `json:"free"`
-				} `json:"partition"`
-			} `json:"Media0"`
-		} `json:"media"`
+		Media     map[string]Media `json:"media"`
 		Interface struct {
 			GigabitEthernet0 struct {
 				Id            string `json:"id"`
@@ -765,52 +768,7 @@ type Metrics struct {
 				} `json:"server"`
 			} `json:"name-server"`
 			Hotspot struct {
-				Host []struct {
-					Mac       string `json:"mac"`
-					Via       string `json:"via"`
-					Ip        string `json:"ip"`
-					Hostname  string `json:"hostname"`
-					Name      string `json:"name"`
-					Interface struct {
-						Id          string `json:"id"`
-						Name        string `json:"name"`
-						Description string `json:"description"`
-					} `json:"interface,omitempty"`
-					Registered    bool     `json:"registered"`
-					Access        string   `json:"access"`
-					Schedule      string   `json:"schedule"`
-					Active        bool     `json:"active"`
-					Rxbytes       int      `json:"rxbytes"`
-					Txbytes       int      `json:"txbytes"`
-					FirstSeen     int      `json:"first-seen,omitempty"`
-					LastSeen      int      `json:"last-seen,omitempty"`
-					Link          string   `json:"link,omitempty"`
-					Ssid          string   `json:"ssid,omitempty"`
-					Ap            string   `json:"ap,omitempty"`
-					Authenticated bool     `json:"authenticated,omitempty"`
-					Txrate        int      `json:"txrate,omitempty"`
-					Uptime        int      `json:"uptime"`
-					Ht            int      `json:"ht,omitempty"`
-					Mode          string   `json:"mode,omitempty"`
-					Gi            int      `json:"gi,omitempty"`
-					Rssi          int      `json:"rssi,omitempty"`
-					Mcs           int      `json:"mcs,omitempty"`
-					Txss          int      `json:"txss,omitempty"`
-					Ebf           bool     `json:"ebf,omitempty"`
-					DlMu          bool     `json:"dl-mu,omitempty"`
-					Field29       []string `json:"_11,omitempty"`
-					Security      string   `json:"security,omitempty"`
-					TrafficShape  struct {
-						Rx       int    `json:"rx"`
-						Tx       int    `json:"tx"`
-						Mode     string `json:"mode"`
-						Schedule string `json:"schedule"`
-					} `json:"traffic-shape"`
-					Roam string `json:"roam,omitempty"`
-					Dhcp struct {
-						Expires int `json:"expires"`
-					} `json:"dhcp,omitempty"`
-				} `json:"host"`
+				Host []Host `json:"host"`
 			} `json:"hotspot"`
 		} `json:"ip"`
 		Acme struct {
@@ -849,15 +807,9 @@ type Metrics struct {
 			} `json:"share"`
 		} `json:"cifs"`
 		Dlna struct {
-			Running   bool `json:"running"`
-			Directory struct {
-				Fbd65Edf34004871970C9D010D087Download struct {
-					MediaType string `json:"media-type"`
-					Mounted   bool   `json:"mounted"`
-					Found     bool   `json:"found"`
-				} `json:"443fbd65-edf3-4004-8719-70c9d010d087:/download"`
-			} `json:"directory"`
-			Db struct {
+			Running   bool                     `json:"running"`
+			Directory map[string]DlnaDirectory `json:"directory"`
+			Db        struct {
 				Name      string `json:"name"`
 				MediaType string `json:"media-type"`
 				Mounted   bool   `json:"mounted"`
@@ -909,51 +861,13 @@ type Metrics struct {
 					Failures int    `json:"failures"`
 					Resolved bool   `json:"resolved"`
 				} `json:"captive"`
-				Hosts struct {
-					YaRu struct {
-						Failures   int    `json:"failures"`
-						Resolved   bool   `json:"resolved"`
-						Accessible bool   `json:"accessible"`
-						Response   string `json:"response"`
-					} `json:"ya.ru"`
-					NicRu struct {
-						Failures   int    `json:"failures"`
-						Resolved   bool   `json:"resolved"`
-						Accessible bool   `json:"accessible"`
-						Response   string `json:"response"`
-					} `json:"nic.ru"`
-					GoogleCom struct {
-						Failures   int    `json:"failures"`
-						Resolved   bool   `json:"resolved"`
-						Accessible bool   `json:"accessible"`
-						Response   string `json:"response"`
-					} `json:"google.com"`
-				} `json:"hosts"`
+				Hosts map[string]InternetHost `json:"hosts"`
 			} `json:"status"`
 		} `json:"internet"`
 		PingCheck struct {
 			Pingcheck []struct {
-				Profile   string `json:"profile"`
-				Interface struct {
-					GigabitEthernet0Vlan4 struct {
-						Successcount int    `json:"successcount"`
-						Failcount    int    `json:"failcount"`
-						Status       string `json:"status"`
-						Ipcache      []struct {
-							Host      string   `json:"host"`
-							Addresses []string `json:"addresses"`
-						} `json:"ipcache"`
-					} `json:"GigabitEthernet0/Vlan4"`
-					ISP struct {
-						Successcount int    `json:"successcount"`
-						Failcount    int    `json:"failcount"`
-						Status       string `json:"status"`
-						Ipcache      []struct {
-							Host      string   `json:"host"`
-							Addresses []string `json:"addresses"`
-						} `json:"ipcache"`
-					} `json:"ISP"`
-				} `json:"interface"`
+				Profile   string                        `json:"profile"`
+				Interface map[string]PingCheckInterface `json:"interface"`
 			} `json:"pingcheck"`
 		} `json:"ping-check"`
 		Clock struct {
@@ -978,19 +892,7 @@ type Metrics struct {
 			} `json:"date"`
 		} `json:"clock"`
 		Usb struct {
-			Device struct {
-				Media0 struct {
-					DEVICE       string `json:"DEVICE"`
-					DEVPATH      string `json:"DEVPATH"`
-					Manufacturer string `json:"manufacturer"`
-					Product      string `json:"product"`
-					Serial       string `json:"serial"`
-					Subsystem    string `json:"subsystem"`
-					Port         string `json:"port"`
-					PowerControl string `json:"power-control"`
-					UsbVersion   string `json:"usb-version"`
-				} `json:"Media0"`
-			} `json:"device"`
+			Device map[string]UsbDevice `json:"device"`
 		} `json:"usb"`
 	} `json:"show"`
 }
@@ -1000,5 +902,175 @@ func (i *Metrics) GetRqBody() io.Reader {
 }
 
 func (i *Metrics) Unmarshal(b io.Reader) error {
-	return json.NewDecoder(b).Decode(i)
+	var err error
+	var buf []byte
+	if buf, err = io.ReadAll(b); err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(buf, i); err != nil {
+		return err
+	}
+
+	// Набор интерфейсов зависит от роутера, поэтому дополнительно разбираем их в map
+	var ifaces struct {
+		Show struct {
+			Interface map[string]Interface `json:"interface"`
+		} `json:"show"`
+	}
+	if err = json.Unmarshal(buf, &ifaces); err != nil {
+		return err
+	}
+	i.Interfaces = ifaces.Show.Interface
+
+	return nil
+}
+
+// InternetHost result of the connectivity check against one host
+type InternetHost struct {
+	Failures   int    `json:"failures"`
+	Resolved   bool   `json:"resolved"`
+	Accessible bool   `json:"accessible"`
+	Response   string `json:"response"`
+}
+
+// PingCheckInterface ping-check profile state on one interface
+type PingCheckInterface struct {
+	Successcount int    `json:"successcount"`
+	Failcount    int    `json:"failcount"`
+	Status       string `json:"status"`
+	Ipcache      []struct {
+		Host      string   `json:"host"`
+		Addresses []string `json:"addresses"`
+	} `json:"ipcache"`
+}
+
+// Media attached storage device, sizes are in bytes
+type Media struct {
+	Usb struct {
+		Port    int    `json:"port"`
+		Version string `json:"version"`
+	} `json:"usb"`
+	State        string `json:"state"`
+	Manufacturer string `json:"manufacturer"`
+	Product      string `json:"product"`
+	Serial       string `json:"serial"`
+	Size         string `json:"size"`
+	Partition    []struct {
+		Uuid   string `json:"uuid"`
+		Label  string `json:"label"`
+		Fstype string `json:"fstype"`
+		State  string `json:"state"`
+		Total  string `json:"total"`
+		Free   string `json:"free"`
+	} `json:"partition"`
+}
+
+type UsbDevice struct {
+	DEVICE       string `json:"DEVICE"`
+	DEVPATH      string `json:"DEVPATH"`
+	Manufacturer string `json:"manufacturer"`
+	Product      string `json:"product"`
+	Serial       string `json:"serial"`
+	Subsystem    string `json:"subsystem"`
+	Port         string `json:"port"`
+	PowerControl string `json:"power-control"`
+	UsbVersion   string `json:"usb-version"`
+}
+
+type DlnaDirectory struct {
+	MediaType string `json:"media-type"`
+	Mounted   bool   `json:"mounted"`
+	Found     bool   `json:"found"`
+}
+
+// Host client of the router from show ip hotspot
+type Host struct {
+	Mac       string `json:"mac"`
+	Via       string `json:"via"`
+	Ip        string `json:"ip"`
+	Hostname  string `json:"hostname"`
+	Name      string `json:"name"`
+	Interface struct {
+		Id          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"interface,omitempty"`
+	Registered    bool     `json:"registered"`
+	Access        string   `json:"access"`
+	Schedule      string   `json:"schedule"`
+	Active        bool     `json:"active"`
+	Rxbytes       int      `json:"rxbytes"`
+	Txbytes       int      `json:"txbytes"`
+	FirstSeen     int      `json:"first-seen,omitempty"`
+	LastSeen      int      `json:"last-seen,omitempty"`
+	Link          string   `json:"link,omitempty"`
+	Ssid          string   `json:"ssid,omitempty"`
+	Ap            string   `json:"ap,omitempty"`
+	Authenticated bool     `json:"authenticated,omitempty"`
+	Txrate        int      `json:"txrate,omitempty"`
+	Uptime        int      `json:"uptime"`
+	Ht            int      `json:"ht,omitempty"`
+	Mode          string   `json:"mode,omitempty"`
+	Gi            int      `json:"gi,omitempty"`
+	Rssi          int      `json:"rssi,omitempty"`
+	Mcs           int      `json:"mcs,omitempty"`
+	Txss          int      `json:"txss,omitempty"`
+	Ebf           bool     `json:"ebf,omitempty"`
+	DlMu          bool     `json:"dl-mu,omitempty"`
+	Field29       []string `json:"_11,omitempty"`
+	Security      string   `json:"security,omitempty"`
+	TrafficShape  struct {
+		Rx       int    `json:"rx"`
+		Tx       int    `json:"tx"`
+		Mode     string `json:"mode"`
+		Schedule string `json:"schedule"`
+	} `json:"traffic-shape"`
+	Roam string `json:"roam,omitempty"`
+	Dhcp struct {
+		Expires int `json:"expires"`
+	} `json:"dhcp,omitempty"`
+}
+
+// Interface fields common to every entry of show interface
+type Interface struct {
+	Id            string `json:"id"`
+	Index         int    `json:"index"`
+	Type          string `json:"type"`
+	Description   string `json:"description"`
+	InterfaceName string `json:"interface-name"`
+	Link          string `json:"link"`
+	Connected     string `json:"connected"`
+	State         string `json:"state"`
+	Mtu           int    `json:"mtu"`
+	Address       string `json:"address"`
+	Mask          string `json:"mask"`
+	Uptime        int    `json:"uptime"`
+	Global        bool   `json:"global"`
+	Defaultgw     bool   `json:"defaultgw"`
+	Priority      int    `json:"priority"`
+	Mac           string `json:"mac"`
+
+	Wireguard *Wireguard `json:"wireguard,omitempty"`
+}
+
+// Wireguard tunnel state, present only on Wireguard* interfaces
+type Wireguard struct {
+	PublicKey  string          `json:"public-key"`
+	ListenPort int             `json:"listen-port"`
+	Status     string          `json:"status"`
+	Peer       []WireguardPeer `json:"peer"`
+}
+
+type WireguardPeer struct {
+	PublicKey     string `json:"public-key"`
+	Local         string `json:"local"`
+	LocalPort     int    `json:"local-port"`
+	Via           string `json:"via"`
+	Remote        string `json:"remote"`
+	RemotePort    int    `json:"remote-port"`
+	Rxbytes       int64  `json:"rxbytes"`
+	Txbytes       int64  `json:"txbytes"`
+	LastHandshake int    `json:"last-handshake"`
+	Online        bool   `json:"online"`
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// secretKeys атрибуты, которые никогда не пишутся в лог
+var secretKeys = map[string]bool{"password": true, "challenge": true, "hash": true, "cookie": true, "key": true}
+
+// newLogger builds the logger from KeeneticLogFormat (logfmt, json) and KeeneticLogLevel
+func newLogger() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("KeeneticLogLevel"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	var opts = &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if secretKeys[strings.ToLower(a.Key)] && a.Value.String() != "" {
+				a.Value = slog.StringValue("[REDACTED]")
+			}
+			return a
+		},
+	}
+
+	if os.Getenv("KeeneticLogFormat") == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// kitLogger go-kit logger для exporter-toolkit поверх slog
+type kitLogger struct {
+	l *slog.Logger
+}
+
+func (k kitLogger) Log(keyvals ...interface{}) error {
+	var msg string
+	var level = slog.LevelInfo
+	var args = make([]interface{}, 0, len(keyvals))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		var key = fmt.Sprint(keyvals[i])
+		switch key {
+		case "msg":
+			msg = fmt.Sprint(keyvals[i+1])
+		case "level":
+			_ = level.UnmarshalText([]byte(fmt.Sprint(keyvals[i+1])))
+		default:
+			args = append(args, key, keyvals[i+1])
+		}
+	}
+	k.l.Log(context.Background(), level, msg, args...)
+	return nil
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"encoding/base64"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/Tomansru/keeneteus/keenetic_api"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 )
 
 var (
@@ -18,103 +23,249 @@ var (
 		Name: "keeneteus_cpu_load",
 		Help: "Current load of the CPU",
 	})
-	memUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "keeneteus_mem_usage",
-		Help: "Current mem usage",
-	}, []string{"type"})
 	uptimeStat = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "keeneteus_uptime",
 		Help: "Uptime metric",
 	})
-	networkStat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "keeneteus_network",
-		Help: "Used traffic per interface",
-	}, []string{"interface", "rxtx"})
-	devicesStat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "keeneteus_devices",
-		Help: "Used traffic per devices",
-	}, []string{"device", "rxtx"})
-	devicesRssiStat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "keeneteus_devices_rssi",
-		Help: "Used traffic per devices",
-	}, []string{"device"})
 )
 
-func main() {
-	var kUrl, kUser, kPasswd = os.Getenv("KeeneticUrl"),
-		os.Getenv("KeeneticUser"),
-		os.Getenv("KeeneticPassword")
+// pollInterval период опроса роутера
+const pollInterval = time.Second * 3
 
-	var kApi = keenetic_api.NewApi(kUrl, kUser, kPasswd)
+func main() {
+	var logger = newLogger()
+	slog.SetDefault(logger)
 
 	var err error
+	var cfg = &configHolder{}
+	var rl = &reloader{path: os.Getenv("KeeneticConfig"), cfg: cfg}
+	if err = rl.reload(); err != nil {
+		os.Exit(1)
+	}
+
+	var kUrl, kUser string
+	if kUrl, err = getenv("KeeneticUrl"); err != nil {
+		logger.Error("can't read KeeneticUrl", "err", err)
+		os.Exit(1)
+	}
+	if kUser, err = getenv("KeeneticUser"); err != nil {
+		logger.Error("can't read KeeneticUser", "err", err)
+		os.Exit(1)
+	}
+
+	var tlsOpts []keenetic_api.Option
+	if tlsOpts, err = routerTLSOptions(kUrl); err != nil {
+		logger.Error("bad router TLS settings", "err", err)
+		os.Exit(1)
+	}
+
+	var observer *keenetic_api.PrometheusObserver
+	if observer, err = keenetic_api.NewPrometheusObserver(prometheus.DefaultRegisterer); err != nil {
+		logger.Error("can't register client metrics", "err", err)
+		os.Exit(1)
+	}
+
+	// Роутер слабый: не больше 2 запросов одновременно и 2 в секунду, если не задано иное
+	var maxConcurrent, rateLimit = 2, 2.0
+	if v, err := strconv.Atoi(os.Getenv("KeeneticMaxConcurrent")); err == nil {
+		maxConcurrent = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("KeeneticRateLimit"), 64); err == nil {
+		rateLimit = v
+	}
+
+	var opts = append(tlsOpts,
+		keenetic_api.WithPasswordFunc(routerPassword(cfg)),
+		keenetic_api.WithLogger(logger),
+		keenetic_api.WithObserver(observer),
+		keenetic_api.WithMaxConcurrent(maxConcurrent),
+		keenetic_api.WithRateLimit(rateLimit, 2*maxConcurrent))
+	if path := os.Getenv("KeeneticSessionFile"); path != "" {
+		var key string
+		if key, err = getenv("KeeneticSessionKey"); err != nil {
+			logger.Error("can't read KeeneticSessionKey", "err", err)
+			os.Exit(1)
+		}
+		// Ключ - 32 случайных байта в base64, пароль сюда не подходит
+		var raw []byte
+		if raw, err = base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 32 {
+			logger.Error("KeeneticSessionKey must be 32 random bytes in base64 (openssl rand -base64 32)")
+			os.Exit(1)
+		}
+		var sessionKey [32]byte
+		copy(sessionKey[:], raw)
+		opts = append(opts, keenetic_api.WithSessionFile(path, sessionKey))
+	}
+
+	var kApi = keenetic_api.NewApi(kUrl, kUser, "", opts...)
+
 	if err = kApi.Auth(); err != nil {
-		fmt.Println(err)
+		logger.Error("can't authorize on the router", "err", err)
 		os.Exit(1)
 	}
 
-	prometheus.MustRegister(cpuLoad, memUsage, uptimeStat, networkStat, devicesStat, devicesRssiStat)
+	// Время роутера вместо времени скрейпа, чтобы задержки RCI не искажали rate
+	var timestamps, _ = strconv.ParseBool(os.Getenv("KeeneticRouterTimestamps"))
+
+	var snap snapshot
+	var traffic = newTrafficCollector()
+
+	prometheus.MustRegister(cpuLoad, uptimeStat, routerInsecure, configReloadSuccess, configReloadTime)
+	prometheus.MustRegister(&wireguardCollector{snap: &snap, cfg: cfg},
+		&internetCollector{snap: &snap},
+		&pingcheckCollector{snap: &snap},
+		&storageCollector{snap: &snap},
+		&versionCollector{snap: &snap},
+		&memoryCollector{snap: &snap},
+		&servicesCollector{snap: &snap},
+		&clockCollector{snap: &snap},
+		&nameServerCollector{snap: &snap},
+		&interfaceCollector{snap: &snap, timestamps: timestamps},
+		traffic,
+		&hostsCollector{snap: &snap, cfg: cfg})
 
+	// SIGTERM/SIGINT: останавливаем опрос, дожидаемся текущих скрейпов и закрываем сессию на роутере
+	var ctx, stop = signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var pollCtx, stopPoll = context.WithCancel(ctx)
+	defer stopPoll()
+
+	var hup = make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		var dev string
+		for range hup {
+			_ = rl.reload()
+		}
+	}()
+
+	// Детализация графика ip hotspot chart, 0 - по умолчанию роутера
+	var detail int
+	if v := os.Getenv("KeeneticChartDetail"); v != "" {
+		if detail, err = strconv.Atoi(v); err != nil || detail < 0 {
+			logger.Error("KeeneticChartDetail must be a non-negative integer", "value", v)
+			os.Exit(1)
+		}
+	}
+
+	var pollDone = make(chan error, 1)
+	go func() {
+		var err error
 		var upt int
-		var m keenetic_api.Metrics
+		var m *keenetic_api.Metrics
 		var i keenetic_api.InterfaceStat
-		i.SetInterfaces([]keenetic_api.Eth{
-			{Name: "DOM.RU", Code: "GigabitEthernet0/Vlan4"},
-			{Name: "Mishek.NET", Code: "GigabitEthernet1"},
-			{Name: "WGHetzner", Code: "Wireguard0"},
-			{Name: "OfficeVPN", Code: "OpenVPN0"}})
-		i.SetDevices([]keenetic_api.Eth{
-			{Name: "StanislavPC", Code: "18:c0:4d:64:4c:1e"},
-			{Name: "MacBook 2015", Code: "a8:66:7f:2e:4a:d2"},
-			{Name: "OnePlus6T", Code: "c0:ee:fb:4c:60:fd"},
-			{Name: "Multicast", Code: "multicast"},
-			{Name: "Others", Code: "others"}})
-		for range time.Tick(time.Second * 3) {
-			if err = kApi.Metric(&i); err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
+		var current *config
 
-			for _, v := range i.Show.Interface.Stat {
-				networkStat.WithLabelValues(v.InterfaceName, "rx").Set(float64(v.Rxbytes))
-				networkStat.WithLabelValues(v.InterfaceName, "tx").Set(float64(v.Txbytes))
+		var t = time.NewTicker(pollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-pollCtx.Done():
+				pollDone <- nil
+				return
+			case <-t.C:
 			}
 
-			for k, v := range i.Show.Ip.Hotspot.Chart.Bar {
-				dev = i.GetDeviceName(k)
-				for _, v2 := range v.Bars {
-					if v2.Attribute == "" || len(v2.Data) == 0 {
-						break
-					}
-					devicesStat.WithLabelValues(dev, v2.Attribute[:2]).Add(float64(v2.Data[0].V))
-				}
+			// После перезагрузки конфига запрос InterfaceStat собирается заново
+			if c := cfg.get(); c != current {
+				current = c
+				i = keenetic_api.InterfaceStat{}
+				i.SetInterfaces(c.Interfaces)
+				i.SetDevices(c.Devices)
+				i.SetDetail(detail)
+				traffic.prune(c.Devices)
 			}
 
-			if err = kApi.Metric(&m); err != nil {
-				fmt.Println(err)
-				os.Exit(1)
+			if err = kApi.MetricContext(pollCtx, &i); err != nil {
+				pollDone <- err
+				return
 			}
 
-			for _, v := range m.Show.Ip.Hotspot.Host {
-				devicesRssiStat.WithLabelValues(v.Name).Set(float64(v.Rssi))
-				devicesRssiStat.WithLabelValues(v.Name).Set(float64(v.Rssi))
+			snap.setCounters(i.Show.Interface.Stat)
+			traffic.update(&i)
+
+			m = new(keenetic_api.Metrics)
+			if err = kApi.MetricContext(pollCtx, m); err != nil {
+				pollDone <- err
+				return
 			}
+			snap.setMetrics(m)
 
 			upt, _ = strconv.Atoi(m.Show.System.Uptime)
 			uptimeStat.Set(float64(upt))
 			cpuLoad.Set(float64(m.Show.System.Cpuload))
-			memUsage.WithLabelValues("total").Set(float64(m.Show.System.Memtotal))
-			memUsage.WithLabelValues("cache").Set(float64(m.Show.System.Memcache))
-			memUsage.WithLabelValues("free").Set(float64(m.Show.System.Memfree))
-			memUsage.WithLabelValues("buffers").Set(float64(m.Show.System.Membuffers))
 		}
 	}()
 
-	http.Handle("/metrics", promhttp.Handler())
-	if err = http.ListenAndServe("0.0.0.0:2112", nil); err != nil {
-		fmt.Println(err)
+	// TLS и basic auth из web config, файл перечитывается при каждом подключении
+	var webConfig = os.Getenv("KeeneticWebConfig")
+	if err = web.Validate(webConfig); err != nil {
+		logger.Error("bad web config", "path", webConfig, "err", err)
 		os.Exit(1)
 	}
+
+	// Готовность: последний опрос не старше KeeneticReadyIntervals периодов
+	var readyIntervals = 3
+	if v, err := strconv.Atoi(os.Getenv("KeeneticReadyIntervals")); err == nil && v > 0 {
+		readyIntervals = v
+	}
+	var h = &health{snap: &snap, authorized: kApi.Authorized, maxAge: pollInterval * time.Duration(readyIntervals)}
+
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/-/healthy", h.healthy)
+	http.HandleFunc("/-/ready", h.ready)
+	// Как --web.enable-lifecycle у Prometheus: без basic auth из web config перезагрузку мог бы вызвать кто угодно
+	if lifecycle, _ := strconv.ParseBool(os.Getenv("KeeneticEnableLifecycle")); lifecycle {
+		http.HandleFunc("/-/reload", rl.handle)
+	}
+	http.HandleFunc("/", h.landing)
+	var srv = &http.Server{Addr: "0.0.0.0:2112"}
+	var srvDone = make(chan error, 1)
+	go func() {
+		srvDone <- web.ListenAndServe(srv, webConfig, kitLogger{l: logger})
+	}()
+
+	var code int
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	case err = <-pollDone:
+		logger.Error("can't poll the router", "err", err)
+		code = 1
+		pollDone <- nil
+	case err = <-srvDone:
+		logger.Error("http server failed", "err", err)
+		code = 1
+	}
+
+	stopPoll()
+	var shutdownCtx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http server shutdown", "err", err)
+		code = 1
+	}
+	select {
+	case <-pollDone:
+	case <-shutdownCtx.Done():
+		logger.Error("poll did not stop in time")
+		code = 1
+	}
+
+	// С файлом сессии сессию не закрываем, чтобы использовать ее после перезапуска
+	if os.Getenv("KeeneticSessionFile") == "" {
+		if err = kApi.LogoutContext(shutdownCtx); err != nil {
+			logger.Error("can't log out of the router", "err", err)
+			code = 1
+		}
+	}
+
+	os.Exit(code)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	memoryBytes = prometheus.NewDesc("keeneteus_memory_bytes",
+		"Router memory by type: total, free, buffers, cache, used, available",
+		[]string{"type"}, nil)
+	swapBytes = prometheus.NewDesc("keeneteus_swap_bytes",
+		"Router swap by type: total, free, used",
+		[]string{"type"}, nil)
+)
+
+// kb роутер отдает память в килобайтах
+const kb = 1024
+
+// memoryCollector exports memory and swap accounting from show system
+type memoryCollector struct {
+	snap *snapshot
+}
+
+func (c *memoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- memoryBytes
+	ch <- swapBytes
+}
+
+func (c *memoryCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+	var s = &m.Show.System
+
+	var available = s.Memfree + s.Membuffers + s.Memcache
+	for _, v := range []struct {
+		t string
+		v int
+	}{
+		{"total", s.Memtotal},
+		{"free", s.Memfree},
+		{"buffers", s.Membuffers},
+		{"cache", s.Memcache},
+		{"used", s.Memtotal - available},
+		{"available", available},
+	} {
+		ch <- prometheus.MustNewConstMetric(memoryBytes, prometheus.GaugeValue, float64(v.v)*kb, v.t)
+	}
+
+	ch <- prometheus.MustNewConstMetric(swapBytes, prometheus.GaugeValue, float64(s.Swaptotal)*kb, "total")
+	ch <- prometheus.MustNewConstMetric(swapBytes, prometheus.GaugeValue, float64(s.Swapfree)*kb, "free")
+	ch <- prometheus.MustNewConstMetric(swapBytes, prometheus.GaugeValue, float64(s.Swaptotal-s.Swapfree)*kb, "used")
+}
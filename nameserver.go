@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	nameServerInfo = prometheus.NewDesc("keeneteus_dns_upstream_info",
+		"Upstream DNS server configured on the router, value is always 1",
+		[]string{"address", "port", "domain", "service", "interface", "global"}, nil)
+	nameServerCount = prometheus.NewDesc("keeneteus_dns_upstreams",
+		"Number of upstream DNS servers by service",
+		[]string{"service"}, nil)
+)
+
+// nameServerCollector exports show ip name-server
+type nameServerCollector struct {
+	snap *snapshot
+}
+
+func (c *nameServerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nameServerInfo
+	ch <- nameServerCount
+}
+
+func (c *nameServerCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+
+	var count = map[string]int{}
+	for _, v := range m.Show.Ip.NameServer.Server {
+		// Обычный DNS приходит без service
+		var service = v.Service
+		if service == "" {
+			service = "plain"
+		}
+		count[service]++
+
+		ch <- prometheus.MustNewConstMetric(nameServerInfo, prometheus.GaugeValue, 1,
+			v.Address, v.Port, v.Domain, service, v.Interface, strconv.Itoa(v.Global))
+	}
+
+	for service, n := range count {
+		ch <- prometheus.MustNewConstMetric(nameServerCount, prometheus.GaugeValue, float64(n), service)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pingcheckSuccess = prometheus.NewDesc("keeneteus_pingcheck_success_total",
+		"Successful ping-check probes",
+		[]string{"profile", "interface"}, nil)
+	pingcheckFail = prometheus.NewDesc("keeneteus_pingcheck_fail_total",
+		"Failed ping-check probes",
+		[]string{"profile", "interface"}, nil)
+	pingcheckStatus = prometheus.NewDesc("keeneteus_pingcheck_status",
+		"Ping-check status, 1 if the interface passes the check",
+		[]string{"profile", "interface"}, nil)
+	pingcheckTarget = prometheus.NewDesc("keeneteus_pingcheck_target_info",
+		"Resolved ping-check target, value is always 1",
+		[]string{"profile", "interface", "host", "address"}, nil)
+)
+
+// pingcheckCollector exports every ping-check profile found on the router
+type pingcheckCollector struct {
+	snap *snapshot
+}
+
+func (c *pingcheckCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pingcheckSuccess
+	ch <- pingcheckFail
+	ch <- pingcheckStatus
+	ch <- pingcheckTarget
+}
+
+func (c *pingcheckCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+
+	for _, p := range m.Show.PingCheck.Pingcheck {
+		for iface, v := range p.Interface {
+			ch <- prometheus.MustNewConstMetric(pingcheckSuccess, prometheus.CounterValue, float64(v.Successcount), p.Profile, iface)
+			ch <- prometheus.MustNewConstMetric(pingcheckFail, prometheus.CounterValue, float64(v.Failcount), p.Profile, iface)
+			ch <- prometheus.MustNewConstMetric(pingcheckStatus, prometheus.GaugeValue, boolToFloat(v.Status == "pass"), p.Profile, iface)
+
+			for _, h := range v.Ipcache {
+				for _, addr := range h.Addresses {
+					ch <- prometheus.MustNewConstMetric(pingcheckTarget, prometheus.GaugeValue, 1, p.Profile, iface, h.Host, addr)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	configReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "keeneteus_config_last_reload_successful",
+		Help: "Whether the last configuration reload attempt was successful",
+	})
+	configReloadTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "keeneteus_config_last_reload_success_timestamp_seconds",
+		Help: "Timestamp of the last successful configuration reload",
+	})
+)
+
+// reloader перечитывает конфиг по SIGHUP и POST /-/reload (только с KeeneticEnableLifecycle)
+type reloader struct {
+	mu   sync.Mutex
+	path string
+	cfg  *configHolder
+}
+
+// reload validates the config file and swaps it in, the old config stays on error
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cfg, err = loadConfig(r.path)
+	if err == nil {
+		err = cfg.validate()
+	}
+	if err != nil {
+		configReloadSuccess.Set(0)
+		slog.Error("config reload failed", "path", r.path, "err", err)
+		return err
+	}
+
+	r.cfg.set(&cfg)
+	configReloadSuccess.Set(1)
+	configReloadTime.SetToCurrentTime()
+	slog.Info("config reloaded", "path", r.path)
+	return nil
+}
+
+func (r *reloader) handle(w http.ResponseWriter, rq *http.Request) {
+	if rq.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, _ = w.Write([]byte("OK\n"))
+}
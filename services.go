@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	serviceUp = prometheus.NewDesc("keeneteus_service_up",
+		"Whether the built-in router service is running",
+		[]string{"service"}, nil)
+	cifsShareActive = prometheus.NewDesc("keeneteus_cifs_share_active",
+		"Whether the CIFS share is active",
+		[]string{"mount", "label"}, nil)
+	dlnaDirectoryMounted = prometheus.NewDesc("keeneteus_dlna_directory_mounted",
+		"Whether the DLNA directory is mounted",
+		[]string{"directory", "media_type"}, nil)
+	acmePending = prometheus.NewDesc("keeneteus_acme_pending",
+		"Pending ACME operations by type: account, get, revoke",
+		[]string{"operation"}, nil)
+	acmeQueue = prometheus.NewDesc("keeneteus_acme_queue_size",
+		"ACME certificate queue size by type: reissue, revoke",
+		[]string{"queue"}, nil)
+	acmeRetries = prometheus.NewDesc("keeneteus_acme_retries",
+		"ACME retries of the current operation",
+		nil, nil)
+	acmeDomainError = prometheus.NewDesc("keeneteus_acme_domain_error",
+		"Whether ACME failed for the KeenDNS domain",
+		[]string{"domain"}, nil)
+	ndnsInfo = prometheus.NewDesc("keeneteus_ndns_info",
+		"KeenDNS name and addresses, value is always 1",
+		[]string{"name", "domain", "address", "address6", "access", "access6"}, nil)
+	ndnsUpdated = prometheus.NewDesc("keeneteus_ndns_updated",
+		"Whether the KeenDNS address is up to date",
+		[]string{"name", "domain"}, nil)
+)
+
+// servicesCollector exports state of CIFS, DLNA, torrent, ACME and KeenDNS
+type servicesCollector struct {
+	snap *snapshot
+}
+
+func (c *servicesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- serviceUp
+	ch <- cifsShareActive
+	ch <- dlnaDirectoryMounted
+	ch <- acmePending
+	ch <- acmeQueue
+	ch <- acmeRetries
+	ch <- acmeDomainError
+	ch <- ndnsInfo
+	ch <- ndnsUpdated
+}
+
+func (c *servicesCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+	var s = &m.Show
+
+	ch <- prometheus.MustNewConstMetric(serviceUp, prometheus.GaugeValue, boolToFloat(s.Cifs.Enabled), "cifs")
+	ch <- prometheus.MustNewConstMetric(serviceUp, prometheus.GaugeValue, boolToFloat(s.Dlna.Running), "dlna")
+	ch <- prometheus.MustNewConstMetric(serviceUp, prometheus.GaugeValue, boolToFloat(s.Torrent.Status.State == "running"), "torrent")
+	ch <- prometheus.MustNewConstMetric(serviceUp, prometheus.GaugeValue, boolToFloat(s.Acme.ServerEnabled), "acme")
+
+	for _, v := range s.Cifs.Share {
+		ch <- prometheus.MustNewConstMetric(cifsShareActive, prometheus.GaugeValue, boolToFloat(v.Active), v.Mount, v.Label)
+	}
+
+	for dir, v := range s.Dlna.Directory {
+		ch <- prometheus.MustNewConstMetric(dlnaDirectoryMounted, prometheus.GaugeValue, boolToFloat(v.Mounted), dir, v.MediaType)
+	}
+
+	ch <- prometheus.MustNewConstMetric(acmePending, prometheus.GaugeValue, boolToFloat(s.Acme.AccountPending), "account")
+	ch <- prometheus.MustNewConstMetric(acmePending, prometheus.GaugeValue, boolToFloat(s.Acme.GetPending), "get")
+	ch <- prometheus.MustNewConstMetric(acmePending, prometheus.GaugeValue, boolToFloat(s.Acme.RevokePending), "revoke")
+	ch <- prometheus.MustNewConstMetric(acmeQueue, prometheus.GaugeValue, float64(s.Acme.ReissueQueueSize), "reissue")
+	ch <- prometheus.MustNewConstMetric(acmeQueue, prometheus.GaugeValue, float64(s.Acme.RevokeQueueSize), "revoke")
+	ch <- prometheus.MustNewConstMetric(acmeRetries, prometheus.GaugeValue, float64(s.Acme.Retries))
+	ch <- prometheus.MustNewConstMetric(acmeDomainError, prometheus.GaugeValue, boolToFloat(s.Acme.NdnsDomainError), s.Acme.NdnsDomain)
+
+	if s.Ndns.Name != "" {
+		ch <- prometheus.MustNewConstMetric(ndnsInfo, prometheus.GaugeValue, 1,
+			s.Ndns.Name, s.Ndns.Domain, s.Ndns.Address, s.Ndns.Address6, s.Ndns.Access, s.Ndns.Access6)
+		ch <- prometheus.MustNewConstMetric(ndnsUpdated, prometheus.GaugeValue, boolToFloat(s.Ndns.Updated), s.Ndns.Name, s.Ndns.Domain)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+// snapshot последние данные, полученные с роутера, их читают коллекторы при скрейпе
+type snapshot struct {
+	mu      sync.RWMutex
+	metrics *keenetic_api.Metrics
+	// updated время получения metrics
+	updated time.Time
+
+	counters []keenetic_api.InterfaceCounters
+}
+
+func (s *snapshot) setMetrics(m *keenetic_api.Metrics) {
+	s.mu.Lock()
+	s.metrics = m
+	s.updated = time.Now()
+	s.mu.Unlock()
+}
+
+// getMetrics returns nil until the first successful poll
+func (s *snapshot) getMetrics() *keenetic_api.Metrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metrics
+}
+
+// getMetricsAt returns the last metrics together with the time they were received
+func (s *snapshot) getMetricsAt() (*keenetic_api.Metrics, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metrics, s.updated
+}
+
+// setCounters копирует счетчики, InterfaceStat переиспользуется между опросами
+func (s *snapshot) setCounters(c []keenetic_api.InterfaceCounters) {
+	c = append([]keenetic_api.InterfaceCounters(nil), c...)
+	s.mu.Lock()
+	s.counters = c
+	s.mu.Unlock()
+}
+
+func (s *snapshot) getCounters() []keenetic_api.InterfaceCounters {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.counters
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	storageSize = prometheus.NewDesc("keeneteus_storage_size_bytes",
+		"Partition size",
+		[]string{"media", "partition", "uuid", "label", "fstype"}, nil)
+	storageFree = prometheus.NewDesc("keeneteus_storage_free_bytes",
+		"Free space on the partition",
+		[]string{"media", "partition", "uuid", "label", "fstype"}, nil)
+	storageState = prometheus.NewDesc("keeneteus_storage_partition_state",
+		"Partition state, value is always 1",
+		[]string{"media", "partition", "uuid", "label", "state"}, nil)
+	storageMediaSize = prometheus.NewDesc("keeneteus_storage_media_size_bytes",
+		"Size of the attached media device",
+		[]string{"media"}, nil)
+	usbDeviceInfo = prometheus.NewDesc("keeneteus_usb_device_info",
+		"Attached USB device, value is always 1",
+		[]string{"device", "manufacturer", "product", "serial", "port", "usb_version", "subsystem"}, nil)
+)
+
+// storageCollector exports every attached media device and its partitions
+type storageCollector struct {
+	snap *snapshot
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storageSize
+	ch <- storageFree
+	ch <- storageState
+	ch <- storageMediaSize
+	ch <- usbDeviceInfo
+}
+
+func (c *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+
+	var err error
+	var size float64
+	for media, v := range m.Show.Media {
+		if size, err = strconv.ParseFloat(v.Size, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(storageMediaSize, prometheus.GaugeValue, size, media)
+		}
+
+		// У неразмеченных разделов uuid и label пустые, различаем их по номеру
+		for k, p := range v.Partition {
+			var part = strconv.Itoa(k)
+			ch <- prometheus.MustNewConstMetric(storageState, prometheus.GaugeValue, 1, media, part, p.Uuid, p.Label, p.State)
+			// У неподключенного раздела total и free пустые
+			if size, err = strconv.ParseFloat(p.Total, 64); err == nil {
+				ch <- prometheus.MustNewConstMetric(storageSize, prometheus.GaugeValue, size, media, part, p.Uuid, p.Label, p.Fstype)
+			}
+			if size, err = strconv.ParseFloat(p.Free, 64); err == nil {
+				ch <- prometheus.MustNewConstMetric(storageFree, prometheus.GaugeValue, size, media, part, p.Uuid, p.Label, p.Fstype)
+			}
+		}
+	}
+
+	for dev, v := range m.Show.Usb.Device {
+		ch <- prometheus.MustNewConstMetric(usbDeviceInfo, prometheus.GaugeValue, 1,
+			dev, v.Manufacturer, v.Product, v.Serial, v.Port, v.UsbVersion, v.Subsystem)
+	}
+}
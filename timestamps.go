@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// routerTimeLayout формат поля timestamp в show interface stat, время локальное для роутера
+const routerTimeLayout = time.ANSIC
+
+// routerLocation returns the router timezone from show clock date, nil until it is known.
+// Смещения в tz по POSIX - секунды к западу от UTC.
+func routerLocation(m *keenetic_api.Metrics) *time.Location {
+	if m == nil || len(m.Show.Clock.Date.Tz) == 0 {
+		return nil
+	}
+	var d = &m.Show.Clock.Date
+	var west = d.Tz[0].Stdoffset
+	if d.Tz[0].Usesdst && d.Dst == "active" {
+		west = d.Tz[0].Dstoffset
+	}
+	return time.FixedZone("", -west)
+}
+
+// parseRouterTime parses a router local time in the router timezone
+func parseRouterTime(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(routerTimeLayout, s, loc)
+}
+
+// withTimestamp attaches the router time to the sample if enabled
+func withTimestamp(enabled bool, t time.Time, m prometheus.Metric) prometheus.Metric {
+	if !enabled || t.IsZero() {
+		return m
+	}
+	return prometheus.NewMetricWithTimestamp(t, m)
+}
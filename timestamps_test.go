@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+// Ответы собраны по структурам keenetic_api и принятым допущениям: stdoffset - секунды
+// к западу от UTC (как в POSIX TZ "MSK-3"), timestamp в show interface stat - time.ANSIC
+// в локальном времени роутера. Снятый с роутера ответ нужно подставить сюда.
+const (
+	clockMoscow = `{"show": {"clock": {"date": {"weekday": 1, "day": 19, "month": 10, "year": 2026,
+		"hour": 15, "min": 30, "sec": 12, "msec": 250, "dst": "inactive",
+		"tz": [{"locality": "Europe/Moscow", "stdoffset": -10800, "dstoffset": -10800,
+			"usesdst": false, "rule": "MSK-3", "custom": false}]}}}}`
+	clockBerlinSummer = `{"show": {"clock": {"date": {"weekday": 1, "day": 20, "month": 7, "year": 2026,
+		"hour": 15, "min": 30, "sec": 12, "msec": 0, "dst": "active",
+		"tz": [{"locality": "Europe/Berlin", "stdoffset": -3600, "dstoffset": -7200,
+			"usesdst": true, "rule": "CET-1CEST,M3.5.0,M10.5.0/3", "custom": false}]}}}}`
+	clockBerlinWinter = `{"show": {"clock": {"date": {"weekday": 1, "day": 19, "month": 10, "year": 2026,
+		"hour": 15, "min": 30, "sec": 12, "msec": 0, "dst": "inactive",
+		"tz": [{"locality": "Europe/Berlin", "stdoffset": -3600, "dstoffset": -7200,
+			"usesdst": true, "rule": "CET-1CEST,M3.5.0,M10.5.0/3", "custom": false}]}}}}`
+	clockNoTz = `{"show": {"clock": {"date": {"day": 19, "month": 10, "year": 2026}}}}`
+
+	statWan = `{"rxpackets": 1200, "rxbytes": 987654, "txpackets": 800, "txbytes": 123456,
+		"timestamp": "Mon Oct 19 15:30:12 2026"}`
+)
+
+func TestRouterTime(t *testing.T) {
+	var tests = []struct {
+		name      string
+		clock     string
+		stat      string
+		offset    int
+		wantClock time.Time
+		wantStat  time.Time
+		wantErr   bool
+	}{
+		{"moscow", clockMoscow, statWan, 3 * 3600,
+			time.Date(2026, 10, 19, 12, 30, 12, 250*int(time.Millisecond), time.UTC),
+			time.Date(2026, 10, 19, 12, 30, 12, 0, time.UTC), false},
+		{"berlin dst", clockBerlinSummer, `{"timestamp": "Mon Jul 20 15:30:12 2026"}`, 2 * 3600,
+			time.Date(2026, 7, 20, 13, 30, 12, 0, time.UTC),
+			time.Date(2026, 7, 20, 13, 30, 12, 0, time.UTC), false},
+		{"berlin standard", clockBerlinWinter, `{"timestamp": "Mon Oct  5 09:03:04 2026"}`, 3600,
+			time.Date(2026, 10, 19, 14, 30, 12, 0, time.UTC),
+			time.Date(2026, 10, 5, 8, 3, 4, 0, time.UTC), false},
+		{"unparsable timestamp", clockMoscow, `{"timestamp": "2026-10-19T15:30:12"}`, 3 * 3600,
+			time.Date(2026, 10, 19, 12, 30, 12, 250*int(time.Millisecond), time.UTC), time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m keenetic_api.Metrics
+			if err := json.Unmarshal([]byte(tt.clock), &m); err != nil {
+				t.Fatal(err)
+			}
+			var loc = routerLocation(&m)
+			if loc == nil {
+				t.Fatal("no location")
+			}
+			if _, offset := time.Date(2026, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != tt.offset {
+				t.Errorf("offset = %d, want %d", offset, tt.offset)
+			}
+
+			// Так же собирает время clockCollector
+			var d = &m.Show.Clock.Date
+			var clock = time.Date(d.Year, time.Month(d.Month), d.Day, d.Hour, d.Min, d.Sec, d.Msec*int(time.Millisecond), loc)
+			if !clock.Equal(tt.wantClock) {
+				t.Errorf("clock = %v, want %v", clock.UTC(), tt.wantClock)
+			}
+
+			var c keenetic_api.InterfaceCounters
+			if err := json.Unmarshal([]byte(tt.stat), &c); err != nil {
+				t.Fatal(err)
+			}
+			var got, err = parseRouterTime(c.Timestamp, loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRouterTime(%q) error = %v, want error %v", c.Timestamp, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.wantStat) {
+				t.Errorf("parseRouterTime(%q) = %v, want %v", c.Timestamp, got.UTC(), tt.wantStat)
+			}
+		})
+	}
+
+	var m keenetic_api.Metrics
+	if err := json.Unmarshal([]byte(clockNoTz), &m); err != nil {
+		t.Fatal(err)
+	}
+	if loc := routerLocation(&m); loc != nil {
+		t.Errorf("location without tz = %v, want nil", loc)
+	}
+	if loc := routerLocation(nil); loc != nil {
+		t.Errorf("location before the first poll = %v, want nil", loc)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var routerInsecure = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "keeneteus_router_connection_insecure",
+	Help: "Set to 1 when the connection to the router is not protected: plaintext or skip_verify",
+}, []string{"reason"})
+
+var errBadCA = errors.New("no certificates found in KeeneticCAFile")
+
+// routerTLSOptions reads TLS settings of the router connection from the environment
+func routerTLSOptions(endpoint string) ([]keenetic_api.Option, error) {
+	var opts []keenetic_api.Option
+
+	if path := os.Getenv("KeeneticCAFile"); path != "" {
+		var err error
+		var pem []byte
+		if pem, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		var pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errBadCA
+		}
+		opts = append(opts, keenetic_api.WithRootCAs(pool))
+	}
+
+	if pins := os.Getenv("KeeneticPinSHA256"); pins != "" {
+		opts = append(opts, keenetic_api.WithSPKIPins(strings.Split(pins, ",")...))
+	}
+
+	if name := os.Getenv("KeeneticServerName"); name != "" {
+		opts = append(opts, keenetic_api.WithServerName(name))
+	}
+
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme == "http" {
+		slog.Warn("router password hash and session cookies are sent over plaintext HTTP", "url", endpoint)
+		routerInsecure.WithLabelValues("plaintext").Set(1)
+	}
+
+	if skip, _ := strconv.ParseBool(os.Getenv("KeeneticInsecureSkipVerify")); skip {
+		slog.Warn("router certificate verification is disabled")
+		routerInsecure.WithLabelValues("skip_verify").Set(1)
+		opts = append(opts, keenetic_api.WithInsecureSkipVerify())
+	}
+
+	return opts, nil
+}
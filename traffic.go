@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	deviceRx = prometheus.NewDesc("keeneteus_device_receive_bytes_total",
+		"Bytes received by the device, accumulated from the hotspot traffic chart",
+		[]string{"device"}, nil)
+	deviceTx = prometheus.NewDesc("keeneteus_device_transmit_bytes_total",
+		"Bytes sent by the device, accumulated from the hotspot traffic chart",
+		[]string{"device"}, nil)
+	// devicesStat старое имя, оставлено на время перехода дашбордов
+	devicesStat = prometheus.NewDesc("keeneteus_devices",
+		"Deprecated: use keeneteus_device_receive_bytes_total and keeneteus_device_transmit_bytes_total",
+		[]string{"device", "rxtx"}, nil)
+)
+
+type trafficKey struct {
+	device    string
+	attribute string
+}
+
+// trafficCollector accumulates the hotspot chart into per-device counters.
+// Каждый опрос возвращает окно из нескольких корзин, корзины пересекаются между опросами,
+// поэтому учитываем только прирост значения корзины с тем же T.
+// Время последней корзины к счетчикам не прикладываем: она еще заполняется.
+type trafficCollector struct {
+	mu     sync.Mutex
+	totals map[trafficKey]float64
+	seen   map[trafficKey]map[int64]int64
+}
+
+func newTrafficCollector() *trafficCollector {
+	return &trafficCollector{
+		totals: map[trafficKey]float64{},
+		seen:   map[trafficKey]map[int64]int64{},
+	}
+}
+
+// update adds buckets of a fresh chart not accounted yet
+func (c *trafficCollector) update(i *keenetic_api.InterfaceStat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, bar := range i.Show.Ip.Hotspot.Chart.Bar {
+		var dev = i.GetDeviceName(k)
+		for _, series := range bar.Bars {
+			if series.Attribute == "" {
+				continue
+			}
+
+			var key = trafficKey{device: dev, attribute: series.Attribute}
+			var prev = c.seen[key]
+			var cur = make(map[int64]int64, len(series.Data))
+			for _, p := range series.Data {
+				var base, ok = cur[p.T]
+				if !ok {
+					base = prev[p.T]
+				}
+				if p.V > base {
+					c.totals[key] += float64(p.V - base)
+					base = p.V
+				}
+				cur[p.T] = base
+			}
+			// Корзины, выпавшие из окна, больше не придут
+			c.seen[key] = cur
+		}
+	}
+}
+
+// prune drops the counters of devices removed from the config
+func (c *trafficCollector) prune(devices []keenetic_api.Eth) {
+	var keep = make(map[string]bool, len(devices))
+	for _, d := range devices {
+		keep[d.Name] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.totals {
+		if !keep[k.device] {
+			delete(c.totals, k)
+		}
+	}
+	for k := range c.seen {
+		if !keep[k.device] {
+			delete(c.seen, k)
+		}
+	}
+}
+
+func (c *trafficCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deviceRx
+	ch <- deviceTx
+	ch <- devicesStat
+}
+
+func (c *trafficCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, v := range c.totals {
+		var desc *prometheus.Desc
+		var rxtx string
+		switch k.attribute {
+		case "rxbytes":
+			desc, rxtx = deviceRx, "rx"
+		case "txbytes":
+			desc, rxtx = deviceTx, "tx"
+		default:
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, k.device)
+		ch <- prometheus.MustNewConstMetric(devicesStat, prometheus.GaugeValue, v, k.device, rxtx)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Tomansru/keeneteus/keenetic_api"
+)
+
+func chartStat(points ...keenetic_api.ChartPoint) *keenetic_api.InterfaceStat {
+	var i keenetic_api.InterfaceStat
+	i.SetDevices([]keenetic_api.Eth{{Name: "pc", Code: "18:c0:4d:64:4c:1e"}})
+	i.Show.Ip.Hotspot.Chart.Bar = []keenetic_api.ChartBar{{
+		Mac:  "18:c0:4d:64:4c:1e",
+		Bars: []keenetic_api.ChartSeries{{Attribute: "rxbytes", Data: points}},
+	}}
+	return &i
+}
+
+func TestTrafficUpdate(t *testing.T) {
+	type p = keenetic_api.ChartPoint
+	var tests = []struct {
+		name  string
+		polls [][]p
+		want  []float64
+	}{
+		{"sums buckets", [][]p{{{T: 1, V: 10}, {T: 2, V: 20}}}, []float64{30}},
+		{"same window", [][]p{{{T: 1, V: 10}, {T: 2, V: 20}}, {{T: 1, V: 10}, {T: 2, V: 20}}}, []float64{30, 30}},
+		{"growing bucket", [][]p{{{T: 1, V: 10}, {T: 2, V: 5}}, {{T: 1, V: 10}, {T: 2, V: 20}, {T: 3, V: 7}}}, []float64{15, 37}},
+		{"bucket leaves window", [][]p{{{T: 1, V: 10}, {T: 2, V: 20}}, {{T: 2, V: 20}, {T: 3, V: 5}}}, []float64{30, 35}},
+		{"missed poll", [][]p{{{T: 1, V: 10}}, {{T: 3, V: 5}, {T: 4, V: 6}}}, []float64{10, 21}},
+		{"repeated T", [][]p{{{T: 1, V: 10}, {T: 1, V: 10}}}, []float64{10}},
+		{"repeated T grows", [][]p{{{T: 1, V: 10}, {T: 1, V: 15}}, {{T: 1, V: 15}}}, []float64{15, 15}},
+		{"bucket shrinks", [][]p{{{T: 1, V: 10}}, {{T: 1, V: 4}}}, []float64{10, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c = newTrafficCollector()
+			var key = trafficKey{device: "pc", attribute: "rxbytes"}
+			for k, data := range tt.polls {
+				c.update(chartStat(data...))
+				if got := c.totals[key]; got != tt.want[k] {
+					t.Errorf("poll %d: total = %v, want %v", k, got, tt.want[k])
+				}
+			}
+		})
+	}
+}
+
+func TestTrafficPrune(t *testing.T) {
+	var c = newTrafficCollector()
+	c.update(chartStat(keenetic_api.ChartPoint{T: 1, V: 10}))
+
+	c.prune([]keenetic_api.Eth{{Name: "pc", Code: "18:c0:4d:64:4c:1e"}})
+	if len(c.totals) != 1 || len(c.seen) != 1 {
+		t.Fatalf("configured device pruned: totals %v", c.totals)
+	}
+
+	c.prune([]keenetic_api.Eth{{Name: "laptop", Code: "a8:66:7f:2e:4a:d2"}})
+	if len(c.totals) != 0 || len(c.seen) != 0 {
+		t.Errorf("removed device kept: totals %v", c.totals)
+	}
+}
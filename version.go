@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	routerInfo = prometheus.NewDesc("keeneteus_router_info",
+		"Router hardware and firmware, value is always 1",
+		[]string{"release", "title", "arch", "sandbox", "ndm_exact", "ndm_date", "bsp_exact", "bsp_date",
+			"manufacturer", "vendor", "series", "model", "hw_version", "hw_id", "device", "region"}, nil)
+	firmwareBuild = prometheus.NewDesc("keeneteus_firmware_build_timestamp_seconds",
+		"Build time of the router firmware",
+		[]string{"release"}, nil)
+)
+
+// cdateLayouts форматы даты сборки, встречающиеся в show version
+var cdateLayouts = []string{"2 Jan 2006", "02 Jan 2006", "2006-01-02", time.RFC3339}
+
+// versionCollector exports show version
+type versionCollector struct {
+	snap *snapshot
+}
+
+func (c *versionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- routerInfo
+	ch <- firmwareBuild
+}
+
+func (c *versionCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+	var v = &m.Show.Version
+
+	ch <- prometheus.MustNewConstMetric(routerInfo, prometheus.GaugeValue, 1,
+		v.Release, v.Title, v.Arch, v.Sandbox, v.Ndm.Exact, v.Ndm.Cdate, v.Bsp.Exact, v.Bsp.Cdate,
+		v.Manufacturer, v.Vendor, v.Series, v.Model, v.HwVersion, v.HwId, v.Device, v.Region)
+
+	for _, l := range cdateLayouts {
+		if t, err := time.Parse(l, v.Ndm.Cdate); err == nil {
+			ch <- prometheus.MustNewConstMetric(firmwareBuild, prometheus.GaugeValue, float64(t.Unix()), v.Release)
+			break
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	wgInterfaceInfo = prometheus.NewDesc("keeneteus_wireguard_interface_info",
+		"WireGuard interface state, value is always 1",
+		[]string{"interface", "description", "public_key", "listen_port", "status"}, nil)
+	wgPeerInfo = prometheus.NewDesc("keeneteus_wireguard_peer_info",
+		"WireGuard peer endpoint, value is always 1",
+		[]string{"interface", "peer", "public_key", "remote", "remote_port", "via"}, nil)
+	wgPeerRx = prometheus.NewDesc("keeneteus_wireguard_peer_receive_bytes_total",
+		"Bytes received from the WireGuard peer",
+		[]string{"interface", "peer"}, nil)
+	wgPeerTx = prometheus.NewDesc("keeneteus_wireguard_peer_transmit_bytes_total",
+		"Bytes sent to the WireGuard peer",
+		[]string{"interface", "peer"}, nil)
+	wgPeerHandshake = prometheus.NewDesc("keeneteus_wireguard_peer_seconds_since_last_handshake",
+		"Seconds since the last handshake with the WireGuard peer",
+		[]string{"interface", "peer"}, nil)
+	wgPeerOnline = prometheus.NewDesc("keeneteus_wireguard_peer_online",
+		"Whether the WireGuard peer is online",
+		[]string{"interface", "peer"}, nil)
+)
+
+// wireguardCollector exports every Wireguard* interface found on the router
+type wireguardCollector struct {
+	snap *snapshot
+	cfg  *configHolder
+}
+
+func (c *wireguardCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- wgInterfaceInfo
+	ch <- wgPeerInfo
+	ch <- wgPeerRx
+	ch <- wgPeerTx
+	ch <- wgPeerHandshake
+	ch <- wgPeerOnline
+}
+
+func (c *wireguardCollector) Collect(ch chan<- prometheus.Metric) {
+	var m = c.snap.getMetrics()
+	if m == nil {
+		return
+	}
+
+	for id, v := range m.Interfaces {
+		if v.Wireguard == nil || !strings.HasPrefix(id, "Wireguard") {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(wgInterfaceInfo, prometheus.GaugeValue, 1,
+			id, v.Description, v.Wireguard.PublicKey, strconv.Itoa(v.Wireguard.ListenPort), v.Wireguard.Status)
+
+		for _, p := range v.Wireguard.Peer {
+			var peer = c.peerName(p.PublicKey)
+			ch <- prometheus.MustNewConstMetric(wgPeerInfo, prometheus.GaugeValue, 1,
+				id, peer, p.PublicKey, p.Remote, strconv.Itoa(p.RemotePort), p.Via)
+			ch <- prometheus.MustNewConstMetric(wgPeerRx, prometheus.CounterValue, float64(p.Rxbytes), id, peer)
+			ch <- prometheus.MustNewConstMetric(wgPeerTx, prometheus.CounterValue, float64(p.Txbytes), id, peer)
+			ch <- prometheus.MustNewConstMetric(wgPeerHandshake, prometheus.GaugeValue, float64(p.LastHandshake), id, peer)
+			ch <- prometheus.MustNewConstMetric(wgPeerOnline, prometheus.GaugeValue, boolToFloat(p.Online), id, peer)
+		}
+	}
+}
+
+// peerName returns the configured alias (Code - public key) or the public key itself
+func (c *wireguardCollector) peerName(key string) string {
+	for _, v := range c.cfg.get().Peers {
+		if v.Code == key {
+			return v.Name
+		}
+	}
+	return key
+}